@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules/Auth"
+	"tp-go-service/modules/Profiles"
+	"tp-go-service/modules/Storage"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes" binding:"required,min=1"`
+	Profile   string   `json:"profile"`
+	ExpiresIn string   `json:"expires_in"`
+}
+
+type RotateAPIKeyRequest struct {
+	Grace string `json:"grace"`
+}
+
+type SaveProfileRequest struct {
+	Token  string `json:"token" binding:"required"`
+	TRS    string `json:"trs" binding:"required"`
+	Marker string `json:"marker" binding:"required"`
+}
+
+// defaultRotationGrace - сколько старый ключ действует после ротации
+const defaultRotationGrace = 24 * time.Hour
+
+func listAPIKeys(c *gin.Context) {
+	keys, err := keyring.List()
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка чтения API-ключей")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка чтения API-ключей"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+func createAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные параметры запроса: " + err.Error()})
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		ttl, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный expires_in"})
+			return
+		}
+		at := time.Now().UTC().Add(ttl)
+		expiresAt = &at
+	}
+
+	req.Profile = Profiles.NormalizeName(req.Profile)
+	if req.Profile != "" {
+		if _, ok := profiles.Get(req.Profile); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "профиль не найден: " + req.Profile})
+			return
+		}
+	}
+
+	key, secret, err := keyring.Issue(req.Name, req.Scopes, req.Profile, expiresAt)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка выпуска API-ключа")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"key_id":   key.ID,
+		"key_name": key.Name,
+		"scopes":   key.Scopes,
+	}).Info("Выпущен API-ключ")
+
+	c.JSON(http.StatusCreated, gin.H{"key": key, "api_key": secret})
+}
+
+func rotateAPIKey(c *gin.Context) {
+	id, ok := keyIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные параметры запроса: " + err.Error()})
+			return
+		}
+	}
+
+	grace := defaultRotationGrace
+	if req.Grace != "" {
+		parsed, err := time.ParseDuration(req.Grace)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "неверный grace"})
+			return
+		}
+		grace = parsed
+	}
+
+	key, secret, err := keyring.Rotate(id, grace)
+	if errors.Is(err, Auth.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API-ключ не найден"})
+		return
+	}
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка ротации API-ключа")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка ротации API-ключа"})
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"old_key_id": id,
+		"key_id":     key.ID,
+		"grace":      grace.String(),
+	}).Info("API-ключ ротирован")
+
+	c.JSON(http.StatusCreated, gin.H{"key": key, "api_key": secret})
+}
+
+func revokeAPIKey(c *gin.Context) {
+	id, ok := keyIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := keyring.Revoke(id)
+	if errors.Is(err, Auth.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API-ключ не найден"})
+		return
+	}
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка отзыва API-ключа")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка отзыва API-ключа"})
+		return
+	}
+
+	requestLog(c).WithField("key_id", id).Info("API-ключ отозван")
+
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+func listProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles.Names()})
+}
+
+// saveProfile сохраняет профиль в базу с зашифрованным токеном
+func saveProfile(c *gin.Context) {
+	var req SaveProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные параметры запроса: " + err.Error()})
+		return
+	}
+
+	name := Profiles.NormalizeName(c.Param("name"))
+
+	profileCipher, err := Profiles.NewCipher(profilesSecret)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	encrypted, err := profileCipher.Encrypt(req.Token)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка шифрования профиля")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка шифрования профиля"})
+		return
+	}
+
+	err = store.SaveCredentialProfile(&Storage.CredentialProfile{
+		Name:           name,
+		TokenEncrypted: encrypted,
+		TRS:            req.TRS,
+		Marker:         req.Marker,
+	})
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка сохранения профиля")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка сохранения профиля"})
+		return
+	}
+
+	profiles.Add(Profiles.Profile{
+		Name:   name,
+		Token:  req.Token,
+		TRS:    req.TRS,
+		Marker: req.Marker,
+	})
+	redactSecrets(current().config.Auth.AdminAPIKey)
+
+	requestLog(c).WithField("profile", name).Info("Профиль учетных данных сохранен")
+
+	c.JSON(http.StatusOK, gin.H{"status": "saved"})
+}
+
+func deleteProfile(c *gin.Context) {
+	name := Profiles.NormalizeName(c.Param("name"))
+
+	if _, ok := profiles.Get(name); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "профиль не найден: " + name})
+		return
+	}
+
+	if err := store.DeleteCredentialProfile(name); err != nil {
+		requestLog(c).WithError(err).Error("Ошибка удаления профиля")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка удаления профиля"})
+		return
+	}
+
+	profiles.Remove(name)
+	redactSecrets(current().config.Auth.AdminAPIKey)
+
+	requestLog(c).WithField("profile", name).Info("Профиль учетных данных удален")
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+func keyIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "неверный id ключа"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules/Auth"
+	"tp-go-service/modules/ManyChat"
+)
+
+// apiKeyContextKey - ключ gin-контекста с ключом клиента
+const apiKeyContextKey = "api_key"
+
+// requireScope проверяет API-ключ и его область доступа. Пока авторизация
+// выключена (AUTH_ENABLED), проверяются только административные маршруты.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := apiKeyFromRequest(c.Request)
+
+		if !current().config.Auth.Enabled && scope != Auth.ScopeAdmin && secret == "" {
+			c.Next()
+			return
+		}
+
+		key, err := keyring.Authenticate(secret)
+		if err != nil {
+			requestLog(c).WithError(err).WithField("path", c.Request.URL.Path).Warn("Отказ в доступе: неверный API-ключ")
+
+			mc := ManyChat.New()
+			c.Abort()
+			respond(c, http.StatusUnauthorized, mc.FromError(err))
+			return
+		}
+
+		if !key.HasScope(scope) {
+			err := Auth.NewAuthError("forbidden", "API-ключ не дает доступа к "+scope)
+			requestLog(c).WithError(err).WithFields(logrus.Fields{
+				"path":     c.Request.URL.Path,
+				"key_name": key.Name,
+			}).Warn("Отказ в доступе: нет области доступа")
+
+			mc := ManyChat.New()
+			c.Abort()
+			respond(c, http.StatusForbidden, mc.FromError(err))
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// apiKeyFromRequest достает ключ из X-API-Key, Authorization: Bearer или ?api_key=
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	return r.URL.Query().Get("api_key")
+}
+
+// currentKey возвращает ключ клиента, если запрос прошел авторизацию
+func currentKey(c *gin.Context) (Auth.Key, bool) {
+	value, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return Auth.Key{}, false
+	}
+	key, ok := value.(Auth.Key)
+	return key, ok
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"tp-go-service/modules/WeGoTrip"
+)
+
+// maxCitiesPageSize - наибольший page_size списка городов
+const maxCitiesPageSize = 100
+
+type ListCitiesRequest struct {
+	Query    string `form:"q"`
+	Domain   string `form:"domain" binding:"omitempty,oneof=ru com"`
+	Match    string `form:"match" binding:"omitempty,oneof=prefix substring"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1"`
+}
+
+// listCities ищет города каталога WeGoTrip для автодополнения
+func listCities(c *gin.Context) {
+	var req ListCitiesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверные параметры запроса: " + err.Error()})
+		return
+	}
+	if req.PageSize > maxCitiesPageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Неверные параметры запроса: page_size должен быть от 1 до %d", maxCitiesPageSize)})
+		return
+	}
+
+	page := req.Page
+	if page == 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize == 0 {
+		pageSize = 20
+	}
+
+	cities := WeGoTrip.SearchCities(WeGoTrip.CitySearch{
+		Query:      req.Query,
+		Domain:     req.Domain,
+		PrefixOnly: req.Match == "prefix",
+	})
+
+	total := len(cities)
+	totalPages := (total + pageSize - 1) / pageSize
+
+	// Страница за последней - пустая; проверка до умножения, чтобы огромный
+	// page не переполнил смещение
+	pageCities := []WeGoTrip.City{}
+	if page <= totalPages {
+		start := (page - 1) * pageSize
+		pageCities = cities[start:min(start+pageSize, total)]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cities":      pageCities,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+		"has_next":    page < totalPages,
+	})
+}
+
+// getCity находит город так же, как getFeed, и возвращает его во всех каталогах,
+// чтобы проверить название до запроса подборки
+func getCity(c *gin.Context) {
+	name := c.Param("name")
+	feed := current().config.Feed
+
+	match, ok := WeGoTrip.ResolveCity(name, feed.CityMinSimilarity)
+	if !ok {
+		suggestions := WeGoTrip.SuggestCities(name, feed.CitySuggestions)
+		if suggestions == nil {
+			suggestions = []string{}
+		}
+
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":       "нет такого города",
+			"code":        "city_not_found",
+			"suggestions": suggestions,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":        match.Name,
+		"match":       match.Method,
+		"similarity":  match.Similarity,
+		"feed_domain": match.Domain,
+		"cities":      WeGoTrip.CitiesNamed(match.Name),
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestListCitiesPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/cities", listCities)
+
+	get := func(query string) (int, map[string]any) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/cities?"+query, nil))
+
+		var body map[string]any
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: ответ не JSON: %s", query, recorder.Body.String())
+		}
+		return recorder.Code, body
+	}
+
+	status, first := get("q=рим&page_size=1")
+	if status != http.StatusOK {
+		t.Fatalf("первая страница: статус %d", status)
+	}
+	totalPages := int(first["total_pages"].(float64))
+	if totalPages < 1 {
+		t.Fatalf("total_pages=%d, ожидался хотя бы один город", totalPages)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "страница сразу за последней", query: "q=рим&page_size=1&page=" + strconv.Itoa(totalPages+1)},
+		{name: "огромная страница", query: "page=9223372036854775807"},
+		{name: "огромная страница с page_size", query: "page=9223372036854775807&page_size=100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := get(tt.query)
+			if status != http.StatusOK {
+				t.Fatalf("статус %d: %v", status, body)
+			}
+
+			cities, ok := body["cities"].([]any)
+			if !ok || len(cities) != 0 {
+				t.Fatalf("cities=%v, ожидался пустой список", body["cities"])
+			}
+			if body["has_next"] != false {
+				t.Fatalf("has_next=%v, ожидалось false", body["has_next"])
+			}
+		})
+	}
+}
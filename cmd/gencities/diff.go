@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cityChange - один город в отчете об изменениях
+type cityChange struct {
+	id      int
+	name    string
+	oldName string
+	oldID   int
+}
+
+// diffReport - изменения таблицы городов относительно прежней
+type diffReport struct {
+	added   []cityChange
+	removed []cityChange
+	renamed []cityChange
+	// moved - названия, у которых сменился ID
+	moved []cityChange
+}
+
+func (r diffReport) empty() bool {
+	return len(r.added)+len(r.removed)+len(r.renamed)+len(r.moved) == 0
+}
+
+// diffCities сравнивает таблицы. Если у города с тем же ID пропало одно
+// название и появилось другое, это переименование; пары составляются
+// по алфавиту, чтобы отчет был одинаковым при каждом запуске.
+func diffCities(previous, next map[string]int) diffReport {
+	var report diffReport
+
+	removedByID := make(map[int][]string)
+	addedByID := make(map[int][]string)
+
+	for name, id := range previous {
+		nextID, ok := next[name]
+		switch {
+		case !ok:
+			removedByID[id] = append(removedByID[id], name)
+		case nextID != id:
+			report.moved = append(report.moved, cityChange{id: nextID, name: name, oldID: id})
+		}
+	}
+	for name, id := range next {
+		if _, ok := previous[name]; !ok {
+			addedByID[id] = append(addedByID[id], name)
+		}
+	}
+
+	for id, removed := range removedByID {
+		added := addedByID[id]
+		sort.Strings(removed)
+		sort.Strings(added)
+
+		pairs := min(len(removed), len(added))
+		for i := 0; i < pairs; i++ {
+			report.renamed = append(report.renamed, cityChange{id: id, name: added[i], oldName: removed[i]})
+		}
+		for _, name := range removed[pairs:] {
+			report.removed = append(report.removed, cityChange{id: id, name: name})
+		}
+		addedByID[id] = added[pairs:]
+	}
+	for id, added := range addedByID {
+		for _, name := range added {
+			report.added = append(report.added, cityChange{id: id, name: name})
+		}
+	}
+
+	for _, changes := range [][]cityChange{report.added, report.removed, report.renamed, report.moved} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].name < changes[j].name
+		})
+	}
+
+	return report
+}
+
+// format печатает отчет: + добавлен, - удален, ~ переименован, ! сменился ID
+func (r diffReport) format(file string, before, after int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d -> %d городов (+%d, -%d, ~%d, !%d)\n",
+		file, before, after, len(r.added), len(r.removed), len(r.renamed), len(r.moved))
+
+	for _, change := range r.added {
+		fmt.Fprintf(&b, "  + %s (%d)\n", change.name, change.id)
+	}
+	for _, change := range r.removed {
+		fmt.Fprintf(&b, "  - %s (%d)\n", change.name, change.id)
+	}
+	for _, change := range r.renamed {
+		fmt.Fprintf(&b, "  ~ %s -> %s (%d)\n", change.oldName, change.name, change.id)
+	}
+	for _, change := range r.moved {
+		fmt.Fprintf(&b, "  ! %s: ID %d -> %d\n", change.name, change.oldID, change.id)
+	}
+
+	return b.String()
+}
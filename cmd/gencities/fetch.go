@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxPages - защита от API, которое не сообщает последнюю страницу
+const maxPages = 1000
+
+// errPageNotFound - API ответил 404: так он может обозначать страницу после последней
+var errPageNotFound = errors.New("страница не найдена")
+
+// fetcher загружает список городов из API WeGoTrip постранично
+type fetcher struct {
+	client   *http.Client
+	path     string
+	lang     string
+	pageSize int
+}
+
+// citiesResponse - страница списка городов; формат как у остальных списков API
+type citiesResponse struct {
+	Data struct {
+		Count   int `json:"count"`
+		Pages   int `json:"pages"`
+		Current int `json:"current"`
+		Results []struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"results"`
+	} `json:"data"`
+}
+
+// fetch возвращает города каталога: название в нижнем регистре -> ID. Страницы
+// читаются до последней из pages; если API не сообщает pages, - до страницы,
+// которая не добавила новых городов (пустой или повторившей предыдущую), или до 404.
+func (f *fetcher) fetch(baseURL string) (map[string]int, error) {
+	cities := make(map[string]int)
+
+	for page := 1; ; page++ {
+		if page > maxPages {
+			return nil, fmt.Errorf("API вернул больше %d страниц", maxPages)
+		}
+
+		response, err := f.fetchPage(baseURL, page)
+		if page > 1 && errors.Is(err, errPageNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("страница %d: %w", page, err)
+		}
+
+		before := len(cities)
+		for _, city := range response.Data.Results {
+			name := strings.ToLower(strings.TrimSpace(city.Name))
+			if name == "" || city.ID <= 0 {
+				continue
+			}
+			if id, ok := cities[name]; ok && id != city.ID {
+				// Одинаковые названия разных городов: оставляем меньший ID,
+				// чтобы результат не зависел от порядка в ответе
+				city.ID = min(id, city.ID)
+			}
+			cities[name] = city.ID
+		}
+
+		if response.Data.Pages > 0 {
+			if page >= response.Data.Pages {
+				break
+			}
+		} else if len(response.Data.Results) == 0 || len(cities) == before {
+			break
+		}
+	}
+
+	if len(cities) == 0 {
+		return nil, errors.New("API не вернул ни одного города")
+	}
+
+	return cities, nil
+}
+
+func (f *fetcher) fetchPage(baseURL string, page int) (citiesResponse, error) {
+	query := url.Values{}
+	query.Set("lang", f.lang)
+	query.Set("page", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(f.pageSize))
+
+	requestURL := strings.TrimRight(baseURL, "/") + f.path + "?" + query.Encode()
+
+	resp, err := f.client.Get(requestURL)
+	if err != nil {
+		return citiesResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return citiesResponse{}, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return citiesResponse{}, fmt.Errorf("%s: %w", requestURL, errPageNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return citiesResponse{}, fmt.Errorf("%s: API вернул ошибку %d", requestURL, resp.StatusCode)
+	}
+
+	var response citiesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return citiesResponse{}, fmt.Errorf("%s: ошибка парсинга ответа: %w", requestURL, err)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "перезаписать эталонные файлы testdata")
+
+type fixtureCity struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+var fixtureCities = []fixtureCity{
+	{ID: 1, Name: "Барселона"},
+	{ID: 2, Name: "Рим"},
+	{ID: 3, Name: "Париж"},
+	{ID: 7, Name: "Нью-Йорк"},
+	{ID: 37, Name: " Вена "},
+}
+
+// citiesServer отдает fixtureCities страницами по page_size. Если reportPages
+// выключен, в ответе нет pages, а страница после последней отвечает 404.
+func citiesServer(t *testing.T, reportPages bool) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/cities/" || r.URL.Query().Get("lang") != "ru" {
+			http.NotFound(w, r)
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		pages := (len(fixtureCities) + size - 1) / size
+		if page < 1 || page > pages {
+			http.NotFound(w, r)
+			return
+		}
+
+		data := map[string]any{
+			"count":   len(fixtureCities),
+			"current": page,
+			"results": fixtureCities[(page-1)*size : min(page*size, len(fixtureCities))],
+		}
+		if reportPages {
+			data["pages"] = pages
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRegenerate(t *testing.T) {
+	overrides, err := loadOverrides("testdata/overrides.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := os.ReadFile("testdata/com_cities_map.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, reportPages := range []bool{true, false} {
+		t.Run("pages="+strconv.FormatBool(reportPages), func(t *testing.T) {
+			server := citiesServer(t, reportPages)
+			client := &fetcher{client: server.Client(), path: "/api/v2/cities/", lang: "ru", pageSize: 2}
+
+			file := filepath.Join(t.TempDir(), targets[0].file)
+			if err := os.WriteFile(file, previous, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var report bytes.Buffer
+			changed, err := regenerate(client, targets[0], server.URL, file, overrides.forDomain("com"),
+				"2026-01-01T00:00:00Z", false, &report, &report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !changed {
+				t.Fatal("ожидались изменения таблицы")
+			}
+
+			compareGolden(t, "testdata/report.golden", report.Bytes())
+
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, "testdata/com_cities_map.golden", source)
+
+			// Повторный запуск по тем же данным ничего не меняет
+			report.Reset()
+			changed, err = regenerate(client, targets[0], server.URL, file, overrides.forDomain("com"),
+				"2026-02-02T00:00:00Z", false, &report, &report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed {
+				t.Fatalf("повторный запуск изменил таблицу:\n%s", report.String())
+			}
+			unchanged, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(unchanged, source) {
+				t.Fatal("повторный запуск перезаписал файл")
+			}
+		})
+	}
+}
+
+func compareGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s не совпадает с эталоном:\n%s", path, got)
+	}
+}
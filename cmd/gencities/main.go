@@ -0,0 +1,128 @@
+// gencities заново генерирует таблицы городов WeGoTrip (com_cities_map.go
+// и ru_cities_map.go) по API городов app.wegotrip.com и wegotrip.ru,
+// применяет исправления ID из файла переопределений и печатает, какие города
+// добавились, пропали или переименованы.
+//
+// Запуск из каталога modules/WeGoTrip:
+//
+//	go generate
+//
+// или с другим адресом API, например локального сервера с тестовыми данными:
+//
+//	go run ./cmd/gencities -dir modules/WeGoTrip -com-url http://localhost:9000 -ru-url http://localhost:9000 -dry-run
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// target - таблица городов одного каталога WeGoTrip
+type target struct {
+	domain string
+	// prefix - префикс имен в сгенерированном файле: COMWeGoTripCities, GetCOMWeGoTripCityID
+	prefix string
+	title  string
+	file   string
+}
+
+var targets = []target{
+	{domain: "com", prefix: "COM", title: "Международные города WeGoTrip (app.wegotrip.com)", file: "com_cities_map.go"},
+	{domain: "ru", prefix: "RU", title: "Российские города WeGoTrip (wegotrip.ru)", file: "ru_cities_map.go"},
+}
+
+func main() {
+	comURL := flag.String("com-url", "https://app.wegotrip.com", "адрес API app.wegotrip.com")
+	ruURL := flag.String("ru-url", "https://wegotrip.ru", "адрес API wegotrip.ru")
+	path := flag.String("path", "/api/v2/cities/", "путь списка городов в API")
+	lang := flag.String("lang", "ru", "язык названий городов")
+	pageSize := flag.Int("page-size", 500, "городов на странице запроса к API")
+	overridesPath := flag.String("overrides", "", "YAML с исправлениями ID городов")
+	dir := flag.String("dir", ".", "каталог, в который пишутся файлы таблиц")
+	date := flag.String("date", "", "дата генерации в формате RFC3339 (по умолчанию текущая)")
+	dryRun := flag.Bool("dry-run", false, "только показать изменения, не записывая файлы")
+	timeout := flag.Duration("timeout", 30*time.Second, "таймаут запроса к API")
+	flag.Parse()
+
+	generatedAt := time.Now().UTC().Format(time.RFC3339)
+	if *date != "" {
+		parsed, err := time.Parse(time.RFC3339, *date)
+		if err != nil {
+			fail("неверный -date: %v", err)
+		}
+		generatedAt = parsed.UTC().Format(time.RFC3339)
+	}
+
+	overrides := overrideFile{}
+	if *overridesPath != "" {
+		var err error
+		if overrides, err = loadOverrides(*overridesPath); err != nil {
+			fail("файл переопределений: %v", err)
+		}
+	}
+
+	client := &fetcher{
+		client:   &http.Client{Timeout: *timeout},
+		path:     *path,
+		lang:     *lang,
+		pageSize: *pageSize,
+	}
+	baseURLs := map[string]string{"com": *comURL, "ru": *ruURL}
+
+	changed := false
+	for _, t := range targets {
+		file := filepath.Join(*dir, t.file)
+		tableChanged, err := regenerate(client, t, baseURLs[t.domain], file, overrides.forDomain(t.domain),
+			generatedAt, *dryRun, os.Stdout, os.Stderr)
+		if err != nil {
+			fail("%s: %v", t.file, err)
+		}
+		changed = changed || tableChanged
+	}
+
+	if !changed {
+		fmt.Println("Таблицы городов не изменились")
+	}
+}
+
+// regenerate загружает города каталога t, применяет переопределения ID, печатает
+// в out изменения относительно file и, если они есть и это не dryRun,
+// перезаписывает file. Предупреждения о переопределениях пишутся в warnings.
+func regenerate(client *fetcher, t target, baseURL, file string, overrides map[string]int,
+	date string, dryRun bool, out, warnings io.Writer) (bool, error) {
+	cities, err := client.fetch(baseURL)
+	if err != nil {
+		return false, err
+	}
+
+	for _, warning := range applyOverrides(cities, overrides) {
+		fmt.Fprintf(warnings, "%s: %s\n", t.file, warning)
+	}
+
+	previous, err := readTable(file, t.prefix)
+	if err != nil {
+		return false, err
+	}
+
+	report := diffCities(previous, cities)
+	fmt.Fprint(out, report.format(t.file, len(previous), len(cities)))
+	if report.empty() || dryRun {
+		return !report.empty(), nil
+	}
+
+	source, err := render(t, cities, date)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(file, source, 0o644)
+}
+
+func fail(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "gencities: "+format+"\n", args...)
+	os.Exit(1)
+}
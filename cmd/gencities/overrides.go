@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// overrideFile - исправления ID городов, которые API отдает неверно
+// (название -> ID), отдельно для каждого каталога. Алиасы названий сюда не
+// входят: они задаются в DefaultAliases (modules/WeGoTrip/aliases.go) и в
+// data.cities_file и применяются при поиске города.
+type overrideFile struct {
+	COM map[string]int `yaml:"com"`
+	RU  map[string]int `yaml:"ru"`
+}
+
+func loadOverrides(path string) (overrideFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return overrideFile{}, err
+	}
+
+	var overrides overrideFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		return overrideFile{}, err
+	}
+
+	return overrides, nil
+}
+
+func (o overrideFile) forDomain(domain string) map[string]int {
+	if domain == "ru" {
+		return o.RU
+	}
+	return o.COM
+}
+
+// applyOverrides заменяет ID городов в таблице cities. Переопределяются только
+// названия, которые вернул API, и только на ID, которые в API есть: новые
+// названия генератор не добавляет. Возвращает предупреждения для отчета.
+func applyOverrides(cities map[string]int, overrides map[string]int) []string {
+	ids := make(map[int]bool, len(cities))
+	for _, id := range cities {
+		ids[id] = true
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for _, key := range names {
+		id := overrides[key]
+		name := strings.ToLower(strings.TrimSpace(key))
+		current, known := cities[name]
+		switch {
+		case name == "":
+			warnings = append(warnings, "пустое название пропущено")
+		case !known:
+			warnings = append(warnings, fmt.Sprintf("%q пропущено: такого названия нет в API (алиасы задаются в DefaultAliases)", name))
+		case !ids[id]:
+			warnings = append(warnings, fmt.Sprintf("%q пропущено: города %d нет в API", name, id))
+		case current == id:
+			warnings = append(warnings, fmt.Sprintf("%q: API уже отдает ID %d, переопределение можно удалить", name, id))
+		default:
+			warnings = append(warnings, fmt.Sprintf("%q: ID %d заменен на %d", name, current, id))
+			cities[name] = id
+		}
+	}
+
+	return warnings
+}
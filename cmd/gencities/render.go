@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"text/template"
+)
+
+var fileTemplate = template.Must(template.New("cities").Parse(`// {{.Title}}
+// Этот файл автоматически сгенерирован
+// Дата генерации: {{.Date}}
+// Всего городов: {{len .Names}}
+// КЛЮЧИ В НИЖНЕМ РЕГИСТРЕ для удобного поиска
+// НЕ РЕДАКТИРУЙТЕ ВРУЧНУЮ!
+
+package WeGoTrip
+
+import "strings"
+
+// Get{{.Prefix}}WeGoTripCityID возвращает ID города WeGoTrip по названию
+// Возвращает 0 если город не найден
+func Get{{.Prefix}}WeGoTripCityID(cityName string) int {
+	return {{.Prefix}}WeGoTripCities[strings.ToLower(cityName)]
+}
+
+// GetAll{{.Prefix}}WeGoTripCities возвращает map всех городов WeGoTrip
+// Ключи в нижнем регистре
+func GetAll{{.Prefix}}WeGoTripCities() map[string]int {
+	return {{.Prefix}}WeGoTripCities
+}
+
+// {{.Prefix}}WeGoTripCities - map городов WeGoTrip (название в нижнем регистре -> ID)
+var {{.Prefix}}WeGoTripCities = map[string]int{
+{{- range .Names}}
+	{{printf "%q" .}}: {{index $.Cities .}},
+{{- end}}
+}
+`))
+
+// render формирует исходный код таблицы; ключи идут по возрастанию,
+// поэтому одинаковые данные дают одинаковый файл
+func render(t target, cities map[string]int, date string) ([]byte, error) {
+	names := make([]string, 0, len(cities))
+	for name := range cities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	err := fileTemplate.Execute(&buf, struct {
+		Title  string
+		Prefix string
+		Date   string
+		Names  []string
+		Cities map[string]int
+	}{t.title, t.prefix, date, names, cities})
+	if err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// readTable читает таблицу <prefix>WeGoTripCities из ранее сгенерированного
+// файла; если файла нет, возвращает пустую таблицу
+func readTable(path, prefix string) (map[string]int, error) {
+	source, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]int{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, source, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	object := file.Scope.Lookup(prefix + "WeGoTripCities")
+	if object == nil {
+		return nil, fmt.Errorf("не найдена переменная %sWeGoTripCities", prefix)
+	}
+	spec, ok := object.Decl.(*ast.ValueSpec)
+	if !ok || len(spec.Values) != 1 {
+		return nil, fmt.Errorf("%sWeGoTripCities: ожидается map-литерал", prefix)
+	}
+	literal, ok := spec.Values[0].(*ast.CompositeLit)
+	if !ok {
+		return nil, fmt.Errorf("%sWeGoTripCities: ожидается map-литерал", prefix)
+	}
+
+	cities := make(map[string]int, len(literal.Elts))
+	for _, element := range literal.Elts {
+		pair, ok := element.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, fmt.Errorf("%sWeGoTripCities: неожиданный элемент", prefix)
+		}
+		key, keyOK := pair.Key.(*ast.BasicLit)
+		value, valueOK := pair.Value.(*ast.BasicLit)
+		if !keyOK || !valueOK {
+			return nil, fmt.Errorf("%sWeGoTripCities: ожидаются строка и число", prefix)
+		}
+
+		name, err := strconv.Unquote(key.Value)
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.Atoi(value.Value)
+		if err != nil {
+			return nil, err
+		}
+		cities[name] = id
+	}
+
+	return cities, nil
+}
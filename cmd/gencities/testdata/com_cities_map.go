@@ -0,0 +1,30 @@
+// Международные города WeGoTrip (app.wegotrip.com)
+// Этот файл автоматически сгенерирован
+// Дата генерации: 2025-01-01T00:00:00Z
+// Всего городов: 4
+// КЛЮЧИ В НИЖНЕМ РЕГИСТРЕ для удобного поиска
+// НЕ РЕДАКТИРУЙТЕ ВРУЧНУЮ!
+
+package WeGoTrip
+
+import "strings"
+
+// GetCOMWeGoTripCityID возвращает ID города WeGoTrip по названию
+// Возвращает 0 если город не найден
+func GetCOMWeGoTripCityID(cityName string) int {
+	return COMWeGoTripCities[strings.ToLower(cityName)]
+}
+
+// GetAllCOMWeGoTripCities возвращает map всех городов WeGoTrip
+// Ключи в нижнем регистре
+func GetAllCOMWeGoTripCities() map[string]int {
+	return COMWeGoTripCities
+}
+
+// COMWeGoTripCities - map городов WeGoTrip (название в нижнем регистре -> ID)
+var COMWeGoTripCities = map[string]int{
+	"барселона": 1,
+	"вена":      36,
+	"лиссабон":  50,
+	"нью йорк":  7,
+}
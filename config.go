@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules"
+	"tp-go-service/modules/Cache"
+	"tp-go-service/modules/Config"
+	"tp-go-service/modules/RateLimit"
+	"tp-go-service/modules/Tracing"
+)
+
+// defaultConfigPath - файл конфигурации, который читается, если он есть
+const defaultConfigPath = "config.yaml"
+
+// loadConfig разбирает флаги --config и --print-config и загружает настройки.
+// Путь к файлу берется из --config, затем CONFIG_FILE, затем config.yaml, если он есть.
+// При --print-config печатает итоговые настройки без секретов и завершает процесс.
+func loadConfig() (Config.Config, string) {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "путь к файлу конфигурации YAML")
+	printConfig := flag.Bool("print-config", false, "вывести итоговую конфигурацию без секретов и выйти")
+	flag.Parse()
+
+	path := *configPath
+	if path == "" {
+		if _, err := os.Stat(defaultConfigPath); err == nil {
+			path = defaultConfigPath
+		}
+	}
+
+	settings, err := Config.Load(path, os.Environ())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ошибка конфигурации:", err)
+		os.Exit(2)
+	}
+
+	if *printConfig {
+		out, err := settings.Redacted()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Ошибка вывода конфигурации:", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		os.Exit(0)
+	}
+
+	return settings, path
+}
+
+// newLinkCache создает кэш ссылок по настройкам cache
+func newLinkCache(config Config.CacheConfig) Cache.LinkCache {
+	logger.WithFields(logrus.Fields{
+		"ttl":        config.TTL.String(),
+		"size":       config.Size,
+		"persistent": config.Persistent,
+	}).Info("Кэш аффилиатных ссылок настроен")
+
+	if !config.Persistent {
+		return Cache.NewMemory(config.TTL, config.Size)
+	}
+
+	persistent := Cache.NewPersistent(store, config.TTL, config.Size, config.MemorySize)
+	persistent.OnError = func(err error) {
+		logger.WithError(err).Warn("Ошибка постоянного кэша ссылок")
+	}
+	return persistent
+}
+
+// retryConfig переводит настройки upstream.retry в параметры повторов
+func retryConfig(config Config.RetryConfig) modules.RetryConfig {
+	retry := modules.RetryConfig{
+		MaxAttempts:   config.Attempts,
+		BaseDelay:     config.BaseDelay,
+		MaxDelay:      config.MaxDelay,
+		Jitter:        config.Jitter,
+		RetryOn:       config.Statuses,
+		Deadline:      config.Deadline,
+		ClientTimeout: config.ClientTimeout,
+	}
+
+	logger.WithFields(logrus.Fields{
+		"attempts":   retry.MaxAttempts,
+		"base_delay": retry.BaseDelay.String(),
+		"max_delay":  retry.MaxDelay.String(),
+		"jitter":     retry.Jitter,
+		"retry_on":   retry.RetryOn,
+		"deadline":   retry.Deadline.String(),
+	}).Info("Повторы запросов к внешним API настроены")
+
+	return retry
+}
+
+// transportConfig переводит настройки upstream.transport в параметры пула соединений
+func transportConfig(config Config.TransportConfig) modules.TransportConfig {
+	transport := modules.DefaultTransportConfig()
+	transport.MaxIdleConns = config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+	transport.IdleConnTimeout = config.IdleConnTimeout
+	transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	transport.DialTimeout = config.DialTimeout
+	return transport
+}
+
+// breakerConfig переводит настройки upstream.breaker в параметры предохранителей
+func breakerConfig(config Config.BreakerConfig) modules.BreakerConfig {
+	return modules.BreakerConfig{
+		FailureThreshold:    config.FailureThreshold,
+		OpenTimeout:         config.OpenTimeout,
+		HalfOpenMaxRequests: config.HalfOpenRequests,
+	}
+}
+
+// tracingConfig переводит настройки tracing в параметры экспорта трассировок
+func tracingConfig(config Config.TracingConfig) Tracing.Config {
+	logger.WithFields(logrus.Fields{
+		"enabled":      config.Enabled,
+		"endpoint":     config.Endpoint,
+		"sample_ratio": config.SampleRatio,
+	}).Info("Трассировка настроена")
+
+	return Tracing.Config{
+		Enabled:     config.Enabled,
+		Endpoint:    config.Endpoint,
+		ServiceName: config.ServiceName,
+		SampleRatio: config.SampleRatio,
+	}
+}
+
+// newRateLimiters создает ограничители маршрутов. Пустой лимит маршрута
+// заменяется лимитом по умолчанию для клиента или подписчика. Ограничители
+// из previous с тем же лимитом переиспользуются, чтобы перезагрузка
+// конфигурации не обнуляла корзины клиентов.
+func newRateLimiters(config Config.RateLimitConfig, previous map[string]routeLimiters) (map[string]routeLimiters, error) {
+	routes := map[string][2]string{
+		"getFromLink":  {config.GetFromLink, config.GetFromLinkSubscriber},
+		"getFromLinks": {config.GetFromLinks, config.GetFromLinksSubscriber},
+		"getFromBrand": {config.GetFromBrand, config.GetFromBrandSubscriber},
+		"getFeed":      {config.GetFeed, config.GetFeedSubscriber},
+		"cities":       {config.Cities, ""},
+	}
+
+	limiters := make(map[string]routeLimiters, len(routes))
+	for endpoint, limits := range routes {
+		client, err := parseLimit(limits[0], config.Default)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", endpoint, err)
+		}
+		subscriber, err := parseLimit(limits[1], config.SubscriberDefault)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", endpoint, err)
+		}
+
+		limiters[endpoint] = routeLimiters{
+			client:     reuseLimiter(previous[endpoint].client, client),
+			subscriber: reuseLimiter(previous[endpoint].subscriber, subscriber),
+		}
+	}
+
+	return limiters, nil
+}
+
+// reuseLimiter возвращает previous, если его лимит не изменился, иначе новый ограничитель
+func reuseLimiter(previous *RateLimit.Limiter, limit RateLimit.Limit) *RateLimit.Limiter {
+	if previous != nil && previous.Limit() == limit {
+		return previous
+	}
+	return RateLimit.New(limit)
+}
+
+func parseLimit(value, fallback string) (RateLimit.Limit, error) {
+	if value == "" {
+		value = fallback
+	}
+	return RateLimit.ParseLimit(value)
+}
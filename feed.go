@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules"
+	"tp-go-service/modules/ManyChat"
+	"tp-go-service/modules/Storage"
+	"tp-go-service/modules/WeGoTrip"
+)
+
+type GetFeedRequest struct {
+	City     string `json:"city" binding:"required"`
+	Lang     string `json:"lang"`
+	Currency string `json:"currency"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+}
+
+func getFeed(c *gin.Context) {
+	var req GetFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c).WithError(err).Error("Ошибка валидации запроса getFeed")
+
+		mc := ManyChat.New()
+		response := mc.FromValidationError("Неверные параметры запроса: " + err.Error())
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"city":      req.City,
+		"lang":      req.Lang,
+		"currency":  req.Currency,
+		"page":      req.Page,
+		"page_size": req.PageSize,
+	}).Info("Обработка запроса getFeed")
+
+	ctx, cancel := requestContext(c, "getFeed")
+	defer cancel()
+
+	feed, err := weGoTrip.GetFeedContext(ctx, WeGoTrip.FeedQuery{
+		City:     req.City,
+		Lang:     req.Lang,
+		Currency: req.Currency,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+	recordFeedLookup(ctx, req, len(feed.Items), err)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка получения данных о поездках")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"feed_length": len(feed.Items),
+		"total_items": feed.TotalItems,
+		"total_pages": feed.TotalPages,
+	}).Info("Данные о поездках получены успешно")
+
+	rememberCitySlug(ctx, feed)
+
+	mc := ManyChat.New()
+	response := mc.FromWeGoTripFeed(feed)
+
+	respond(c, http.StatusOK, response)
+}
+
+// recordFeedLookup сохраняет запрос подборки в историю; ошибки базы не влияют на ответ
+func recordFeedLookup(ctx context.Context, req GetFeedRequest, items int, feedErr modules.APIError) {
+	lookup := &Storage.FeedLookup{
+		City:     req.City,
+		Lang:     req.Lang,
+		Currency: req.Currency,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Items:    items,
+	}
+	if feedErr != nil {
+		lookup.ErrorCode = feedErr.GetCode()
+	}
+
+	if err := store.RecordFeedLookup(lookup); err != nil {
+		contextLog(ctx).WithError(err).Warn("Ошибка сохранения запроса подборки")
+	}
+}
+
+// rememberCitySlug запоминает slug города из подборки для каталога /api/cities
+func rememberCitySlug(ctx context.Context, feed WeGoTrip.Feed) {
+	if feed.CitySlug == "" || WeGoTrip.CitySlug(feed.City.Domain, feed.City.ID) == feed.CitySlug {
+		return
+	}
+
+	WeGoTrip.SetCitySlug(feed.City.Domain, feed.City.ID, feed.CitySlug)
+	if err := store.SaveCitySlug(feed.City.Domain, feed.City.ID, feed.CitySlug); err != nil {
+		contextLog(ctx).WithError(err).Warn("Ошибка сохранения slug города")
+	}
+}
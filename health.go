@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"tp-go-service/modules"
+	"tp-go-service/modules/Config"
+	"tp-go-service/modules/Health"
+	"tp-go-service/modules/WeGoTrip"
+)
+
+// newHealthChecker собирает проверки зависимостей. Внешние API проверяются в
+// фоне отдельным клиентом без повторов и предохранителей, чтобы проверки не
+// влияли на состояние предохранителей.
+func newHealthChecker(transport http.RoundTripper, config Config.HealthConfig, upstream Config.UpstreamConfig) *Health.Checker {
+	probeClient := &http.Client{Transport: transport, Timeout: config.ProbeTimeout}
+
+	checks := []Health.Check{
+		{
+			Name:     "database",
+			Critical: true,
+			Timeout:  config.ProbeTimeout,
+			Probe:    store.Ping,
+		},
+		{
+			Name:     "city_map",
+			Critical: true,
+			Probe: func(context.Context) error {
+				if com, ru := WeGoTrip.CityCounts(); com == 0 && ru == 0 {
+					return errors.New("каталог городов WeGoTrip пуст")
+				}
+				return nil
+			},
+		},
+		{
+			Name:     "travelpayouts",
+			Critical: config.UpstreamsCritical,
+			Interval: config.ProbeInterval,
+			Timeout:  config.ProbeTimeout,
+			Probe:    Health.HTTPProbe(probeClient, upstream.TravelPayoutsURL),
+		},
+		{
+			Name:     "wegotrip",
+			Critical: config.UpstreamsCritical,
+			Interval: config.ProbeInterval,
+			Timeout:  config.ProbeTimeout,
+			Probe:    Health.HTTPProbe(probeClient, upstream.WeGoTripCOMURL),
+		},
+		{
+			// getFeed ходит и в wegotrip.ru - для российских городов
+			Name:     "wegotrip_ru",
+			Critical: config.UpstreamsCritical,
+			Interval: config.ProbeInterval,
+			Timeout:  config.ProbeTimeout,
+			Probe:    Health.HTTPProbe(probeClient, upstream.WeGoTripRUURL),
+		},
+	}
+
+	for _, breaker := range upstreamBreakers {
+		breaker := breaker
+		checks = append(checks, Health.Check{
+			Name: "breaker_" + breaker.Name(),
+			Probe: func(context.Context) error {
+				if state := breaker.State(); state != modules.BreakerClosed {
+					return fmt.Errorf("предохранитель в состоянии %s", state)
+				}
+				return nil
+			},
+		})
+	}
+
+	return Health.New(checks...)
+}
+
+// healthLive отвечает, пока процесс жив, в том числе во время остановки
+func healthLive(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// healthReady отвечает 503, если критичная зависимость недоступна или сервис останавливается
+func healthReady(c *gin.Context) {
+	report := healthChecker.Check(c.Request.Context())
+
+	if draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "draining",
+			"checks": report.Checks,
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules"
+	"tp-go-service/modules/Auth"
+	"tp-go-service/modules/Cache"
+	"tp-go-service/modules/ManyChat"
+	"tp-go-service/modules/Metrics"
+	"tp-go-service/modules/Profiles"
+	"tp-go-service/modules/Storage"
+	"tp-go-service/modules/TravelPayouts"
+)
+
+// LinkParams - необязательные поля запроса, влияющие на создание ссылки
+type LinkParams struct {
+	SubID         string `json:"sub_id"`
+	SubIDTemplate string `json:"sub_id_template"`
+	Channel       string `json:"channel"`
+	Flow          string `json:"flow"`
+	Campaign      string `json:"campaign"`
+	Shorten       *bool  `json:"shorten"`
+}
+
+// CredentialParams - учетные данные Travelpayouts в запросе: профиль сервиса
+// и/или явные поля, заменяющие значения профиля
+type CredentialParams struct {
+	Profile string `json:"profile"`
+	Token   string `json:"token"`
+	TRS     string `json:"trs"`
+	Marker  string `json:"marker"`
+}
+
+type GetFromLinkRequest struct {
+	Link string `json:"link" binding:"required"`
+	CredentialParams
+	LinkParams
+}
+
+type GetFromLinksRequest struct {
+	Links []string `json:"links" binding:"required,min=1,dive,required"`
+	CredentialParams
+	LinkParams
+}
+
+type GetFromBrandRequest struct {
+	BrandName string `json:"brand_name" binding:"required"`
+	CredentialParams
+	LinkParams
+}
+
+// credentials возвращает учетные данные запроса из профиля и полей тела.
+// Запрос без API-ключа передает учетные данные только в теле: профили сервиса
+// ему недоступны. С ключом используется профиль ключа (или профиль по умолчанию);
+// другой профиль можно выбрать только ключом с областью profiles.
+func (p CredentialParams) credentials(c *gin.Context) (TravelPayouts.Credentials, modules.APIError) {
+	overrides := Profiles.Overrides{
+		Token:  p.Token,
+		TRS:    p.TRS,
+		Marker: p.Marker,
+	}
+
+	var profile Profiles.Profile
+	var err modules.APIError
+
+	key, authenticated := currentKey(c)
+	switch {
+	case !authenticated && p.Profile != "":
+		return TravelPayouts.Credentials{}, Auth.NewAuthError("profile_forbidden", "профили учетных данных доступны только с API-ключом")
+	case !authenticated:
+		profile, err = Profiles.Explicit(overrides)
+	case p.Profile != "" && Profiles.NormalizeName(p.Profile) != Profiles.NormalizeName(key.Profile) && !key.HasScope(Auth.ScopeProfiles):
+		return TravelPayouts.Credentials{}, Auth.NewAuthError("profile_forbidden", "API-ключ не дает доступа к профилю "+p.Profile)
+	default:
+		name := p.Profile
+		if name == "" {
+			name = key.Profile
+		}
+		profile, err = profiles.Resolve(name, overrides)
+	}
+	if err != nil {
+		return TravelPayouts.Credentials{}, err
+	}
+
+	return TravelPayouts.NewCredentials(profile.Token, profile.TRS, profile.Marker)
+}
+
+// options возвращает параметры создания ссылки: sub_id по полям запроса
+// и шаблонам сервиса, сокращение ссылки включено по умолчанию
+func (p LinkParams) options() (TravelPayouts.LinkOptions, modules.APIError) {
+	options := TravelPayouts.DefaultLinkOptions()
+	if p.Shorten != nil {
+		options.Shorten = *p.Shorten
+	}
+
+	subID, err := current().subIDTemplates.Resolve(p.SubID, p.SubIDTemplate, TravelPayouts.SubIDVars{
+		Channel:  p.Channel,
+		Flow:     p.Flow,
+		Campaign: p.Campaign,
+	})
+	if err != nil {
+		return options, err
+	}
+	options.SubID = subID
+
+	return options, nil
+}
+
+func getFromLink(c *gin.Context) {
+	var req GetFromLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c).WithError(err).Error("Ошибка валидации запроса getFromLink")
+
+		mc := ManyChat.New()
+		response := mc.FromValidationError("Неверные параметры запроса: " + err.Error())
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"link":    req.Link,
+		"profile": req.Profile,
+		"trs":     req.TRS,
+		"marker":  req.Marker,
+	}).Info("Обработка запроса getFromLink")
+
+	credentials, err := req.credentials(c)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка получения учетных данных Travelpayouts")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	options, err := req.options()
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка формирования параметров ссылки")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	ctx, cancel := requestContext(c, "getFromLink")
+	defer cancel()
+
+	affiliateLink, cacheHit, err := createAffiliateLink(ctx, req.Link, credentials, options)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка создания аффилиатной ссылки")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"affiliate_link": affiliateLink.PartnerURL,
+		"sub_id":         options.SubID,
+		"shorten":        options.Shorten,
+		"cache_hit":      cacheHit,
+		"fallback":       affiliateLink.Fallback,
+	}).Info("Аффилиатная ссылка создана успешно")
+
+	mc := ManyChat.New()
+	response := mc.FromTravelPayoutsResponse(affiliateLink)
+
+	respond(c, http.StatusOK, response)
+}
+
+func getFromLinks(c *gin.Context) {
+	var req GetFromLinksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c).WithError(err).Error("Ошибка валидации запроса getFromLinks")
+
+		mc := ManyChat.New()
+		response := mc.FromValidationError("Неверные параметры запроса: " + err.Error())
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	if len(req.Links) > TravelPayouts.MaxLinksPerRequest {
+		requestLog(c).WithField("links", len(req.Links)).Error("Слишком много ссылок в запросе getFromLinks")
+
+		mc := ManyChat.New()
+		response := mc.FromValidationError(fmt.Sprintf("Неверные параметры запроса: не больше %d ссылок за запрос", TravelPayouts.MaxLinksPerRequest))
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"links":   len(req.Links),
+		"profile": req.Profile,
+		"trs":     req.TRS,
+		"marker":  req.Marker,
+	}).Info("Обработка запроса getFromLinks")
+
+	credentials, err := req.credentials(c)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка получения учетных данных Travelpayouts")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	options, err := req.options()
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка формирования параметров ссылки")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	ctx, cancel := requestContext(c, "getFromLinks")
+	defer cancel()
+
+	results, cacheHits, err := createAffiliateLinks(ctx, req.Links, credentials, options)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка создания аффилиатных ссылок")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+			requestLog(c).WithError(result.Error).WithField("link", result.URL).Warn("Ошибка создания аффилиатной ссылки в пакете")
+		}
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"links":      len(results),
+		"failed":     failed,
+		"sub_id":     options.SubID,
+		"shorten":    options.Shorten,
+		"cache_hits": cacheHits,
+	}).Info("Аффилиатные ссылки созданы")
+
+	mc := ManyChat.New()
+	response := mc.FromTravelPayoutsBatchResponse(results)
+
+	respond(c, http.StatusOK, response)
+}
+
+func getFromBrand(c *gin.Context) {
+	var req GetFromBrandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestLog(c).WithError(err).Error("Ошибка валидации запроса getFromBrand")
+
+		mc := ManyChat.New()
+		response := mc.FromValidationError("Неверные параметры запроса: " + err.Error())
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"brand_name": req.BrandName,
+		"profile":    req.Profile,
+		"trs":        req.TRS,
+		"marker":     req.Marker,
+	}).Info("Обработка запроса getFromBrand")
+
+	credentials, err := req.credentials(c)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка получения учетных данных Travelpayouts")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	options, err := req.options()
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка формирования параметров ссылки")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	brand, err := brands.Resolve(req.BrandName)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка поиска бренда")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	ctx, cancel := requestContext(c, "getFromBrand")
+	defer cancel()
+
+	affiliateLink, cacheHit, err := createAffiliateLink(ctx, brand.URL, credentials, options)
+	if err != nil {
+		requestLog(c).WithError(err).Error("Ошибка создания аффилиатной ссылки для бренда")
+
+		mc := ManyChat.New()
+		response := mc.FromError(err)
+
+		respond(c, http.StatusOK, response)
+		return
+	}
+
+	requestLog(c).WithFields(logrus.Fields{
+		"brand":          brand.Name,
+		"affiliate_link": affiliateLink.PartnerURL,
+		"sub_id":         options.SubID,
+		"shorten":        options.Shorten,
+		"cache_hit":      cacheHit,
+		"fallback":       affiliateLink.Fallback,
+	}).Info("Аффилиатная ссылка для бренда создана успешно")
+
+	mc := ManyChat.New()
+	response := mc.FromTravelPayoutsResponse(affiliateLink)
+
+	respond(c, http.StatusOK, response)
+}
+
+// createAffiliateLink возвращает партнерскую ссылку из кэша или создает ее через Travelpayouts
+func createAffiliateLink(ctx context.Context, link string, credentials TravelPayouts.Credentials, options TravelPayouts.LinkOptions) (TravelPayouts.LinkResult, bool, modules.APIError) {
+	results, cacheHits, err := createAffiliateLinks(ctx, []string{link}, credentials, options)
+	if err != nil {
+		return TravelPayouts.LinkResult{}, false, err
+	}
+
+	if results[0].Error != nil {
+		return TravelPayouts.LinkResult{}, false, results[0].Error
+	}
+
+	return results[0], cacheHits > 0, nil
+}
+
+// createAffiliateLinks конвертирует ссылки пакетом: найденные в кэше берутся
+// из него, остальные отправляются в Travelpayouts одним запросом
+func createAffiliateLinks(ctx context.Context, links []string, credentials TravelPayouts.Credentials, options TravelPayouts.LinkOptions) ([]TravelPayouts.LinkResult, int, modules.APIError) {
+	trs := strconv.Itoa(credentials.TRS)
+	marker := strconv.Itoa(credentials.Marker)
+
+	results := make([]TravelPayouts.LinkResult, len(links))
+	var missing []string
+	var missingIndexes []int
+
+	for i, link := range links {
+		results[i].URL = link
+
+		key := Cache.NewKey(link, trs, marker, options.SubID, options.Shorten)
+		if partnerURL, ok := linkCache.Get(key); ok {
+			results[i].PartnerURL = partnerURL
+			if options.Shorten {
+				results[i].ShortURL = partnerURL
+			} else {
+				results[i].FullURL = partnerURL
+			}
+			continue
+		}
+
+		missing = append(missing, link)
+		missingIndexes = append(missingIndexes, i)
+	}
+
+	cacheHits := len(links) - len(missing)
+	Metrics.ObserveCache("links", cacheHits, len(missing))
+
+	if len(missing) == 0 {
+		return results, cacheHits, nil
+	}
+
+	converted, err := travelPayouts.GetFromLinksContext(ctx, credentials, missing, options)
+	if err != nil && err.GetCode() == "upstream_unavailable" && current().config.Links.FallbackOriginalLink {
+		contextLog(ctx).WithError(err).Warn("Travelpayouts недоступен, возвращаем исходные ссылки")
+
+		for _, i := range missingIndexes {
+			results[i].PartnerURL = links[i]
+			results[i].FullURL = links[i]
+			results[i].Fallback = true
+		}
+		return results, cacheHits, nil
+	}
+	if err != nil {
+		return nil, cacheHits, err
+	}
+
+	for j, result := range converted {
+		results[missingIndexes[j]] = result
+
+		if result.Error != nil {
+			continue
+		}
+
+		linkCache.Set(Cache.NewKey(result.URL, trs, marker, options.SubID, options.Shorten), result.PartnerURL)
+		recordLink(ctx, result, trs, marker, options)
+	}
+
+	return results, cacheHits, nil
+}
+
+// recordLink сохраняет созданную ссылку в историю; ошибки базы не влияют на ответ
+func recordLink(ctx context.Context, result TravelPayouts.LinkResult, trs, marker string, options TravelPayouts.LinkOptions) {
+	err := store.RecordLink(&Storage.Link{
+		OriginalURL: result.URL,
+		PartnerURL:  result.PartnerURL,
+		TRS:         trs,
+		Marker:      marker,
+		SubID:       options.SubID,
+		Shorten:     options.Shorten,
+	})
+	if err != nil {
+		contextLog(ctx).WithError(err).Warn("Ошибка сохранения аффилиатной ссылки")
+	}
+}
@@ -1,166 +1,260 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
-	"os"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"tp-go-service/modules"
+	"tp-go-service/modules/Auth"
+	"tp-go-service/modules/Brands"
+	"tp-go-service/modules/Cache"
+	"tp-go-service/modules/Health"
 	"tp-go-service/modules/ManyChat"
+	"tp-go-service/modules/Metrics"
+	"tp-go-service/modules/Profiles"
+	"tp-go-service/modules/Storage"
+	"tp-go-service/modules/Tracing"
 	"tp-go-service/modules/TravelPayouts"
 	"tp-go-service/modules/WeGoTrip"
 )
 
-type GetFromLinkRequest struct {
-	Link   string `json:"link" binding:"required"`
-	Token  string `json:"token" binding:"required"`
-	TRS    string `json:"trs" binding:"required"`
-	Marker string `json:"marker" binding:"required"`
-}
-
-type GetFromBrandRequest struct {
-	BrandName string `json:"brand_name" binding:"required"`
-	Token     string `json:"token" binding:"required"`
-	TRS       string `json:"trs" binding:"required"`
-	Marker    string `json:"marker" binding:"required"`
-}
-
-type GetFeedRequest struct {
-	City     string `json:"city" binding:"required"`
-	Lang     string `json:"lang"`
-	Currency string `json:"currency"`
-	Page     int    `json:"page"`
-}
-
 var logger *logrus.Logger
+var logFormatter *modules.RedactFormatter
+var brands *Brands.Catalog
+var store *Storage.Storage
+var linkCache Cache.LinkCache
+var profiles *Profiles.Registry
+var profilesSecret string
+var keyring *Auth.Keyring
+var travelPayouts *TravelPayouts.TravelPayouts
+var weGoTrip *WeGoTrip.WeGoTrip
+var upstreamBreakers []*modules.CircuitBreaker
+var healthChecker *Health.Checker
 
 func main() {
+	settings, configPath := loadConfig()
+	configFile = configPath
 
 	logger = logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetLevel(logrus.InfoLevel)
+	logFormatter = modules.NewRedactFormatter(&logrus.JSONFormatter{}, "token", "api_key", "authorization", "secret", "password")
+	logger.SetFormatter(logFormatter)
+	level, _ := logrus.ParseLevel(settings.Log.Level)
+	logger.SetLevel(level)
 
-	gin.SetMode(gin.ReleaseMode)
+	logger.WithField("config_file", configPath).Info("Конфигурация загружена")
 
-	r := gin.New()
-	r.Use(gin.LoggerWithWriter(logger.Out), gin.Recovery())
+	ManyChat.SetDefaults(settings.ManyChat.Version, settings.ManyChat.ContentType)
 
-	r.Use(func(c *gin.Context) {
-		logger.WithFields(logrus.Fields{
-			"method": c.Request.Method,
-			"path":   c.Request.URL.Path,
-			"ip":     c.ClientIP(),
-		}).Info("HTTP Request")
-		c.Next()
-	})
-
-	api := r.Group("/api")
-	{
-		api.POST("/getFromLink", getFromLink)
-		api.POST("/getFeed", getFeed)
+	var err error
+	store, err = Storage.Open(settings.Database.Path)
+	if err != nil {
+		logger.Fatal("Ошибка открытия базы данных: ", err)
 	}
-
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
-	})
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.WithError(err).Warn("Ошибка закрытия базы данных")
+		}
+	}()
+
+	if err := store.SeedBrands(Brands.DefaultBrands); err != nil {
+		logger.Fatal("Ошибка заполнения каталога брендов: ", err)
 	}
 
-	logger.Info("Сервер запущен на порту ", port)
-	if err := r.Run(":" + port); err != nil {
-		logger.Fatal("Ошибка запуска сервера:", err)
+	cities, brandList, err := loadData(settings.Data)
+	if err != nil {
+		logger.Fatal("Ошибка загрузки данных: ", err)
 	}
-}
-
-func getFromLink(c *gin.Context) {
-	var req GetFromLinkRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.WithError(err).Error("Ошибка валидации запроса getFromLink")
+	brands = Brands.New(brandList)
+	WeGoTrip.SetCities(cities)
 
-		mc := ManyChat.New()
-		response := mc.FromValidationError("Неверные параметры запроса: " + err.Error())
-
-		c.JSON(http.StatusOK, response)
-		return
+	slugs, err := store.LoadCitySlugs()
+	if err != nil {
+		logger.Fatal("Ошибка загрузки slug городов: ", err)
+	}
+	for _, slug := range slugs {
+		WeGoTrip.SetCitySlug(slug.Domain, slug.CityID, slug.Slug)
 	}
 
 	logger.WithFields(logrus.Fields{
-		"link":   req.Link,
-		"token":  req.Token,
-		"trs":    req.TRS,
-		"marker": req.Marker,
-	}).Info("Обработка запроса getFromLink")
+		"db_path": settings.Database.Path,
+		"brands":  len(brandList),
+	}).Info("База данных подключена")
 
-	tp, err := TravelPayouts.New(req.Token, req.TRS, req.Marker)
+	logger.WithFields(logrus.Fields{
+		"cities_file": settings.Data.CitiesFile,
+		"cities_com":  len(cities.COM),
+		"cities_ru":   len(cities.RU),
+		"brands_file": settings.Data.BrandsFile,
+	}).Info("Каталоги городов и брендов загружены")
+
+	profilesSecret = settings.Profiles.Secret
+	profiles, err = loadProfiles(settings.Profiles.Default)
 	if err != nil {
-		logger.WithError(err).Error("Ошибка создания TravelPayouts клиента")
+		logger.Fatal("Ошибка загрузки профилей учетных данных: ", err)
+	}
 
-		mc := ManyChat.New()
-		response := mc.FromError(err)
+	keyring = Auth.New(store, settings.Auth.AdminAPIKey)
+	redactSecrets(settings.Auth.AdminAPIKey)
 
-		c.JSON(http.StatusOK, response)
-		return
+	shutdownTracing, err := Tracing.Setup(context.Background(), tracingConfig(settings.Tracing))
+	if err != nil {
+		logger.Fatal("Ошибка настройки трассировки: ", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.WithError(err).Warn("Ошибка отправки трассировок")
+		}
+	}()
+
+	linkCache = newLinkCache(settings.Cache)
+	retry := retryConfig(settings.Upstream.Retry)
+	breaker := breakerConfig(settings.Upstream.Breaker)
+
+	travelPayoutsBreaker := modules.NewCircuitBreaker("travelpayouts", breaker)
+	weGoTripBreaker := modules.NewCircuitBreaker("wegotrip", breaker)
+	upstreamBreakers = []*modules.CircuitBreaker{travelPayoutsBreaker, weGoTripBreaker}
+	for _, breaker := range upstreamBreakers {
+		Metrics.RegisterBreaker(breaker)
 	}
 
-	affiliateLink, err := tp.GetFromLink(req.Link)
+	transport := modules.NewTransport(transportConfig(settings.Upstream.Transport))
+	travelPayouts = TravelPayouts.New(
+		modules.NewUpstreamClient(Tracing.NewTransport(Metrics.NewTransport(transport, "travelpayouts"), "travelpayouts"), retry, travelPayoutsBreaker),
+		settings.Upstream.TravelPayoutsURL,
+	)
+	weGoTrip = WeGoTrip.New(
+		modules.NewUpstreamClient(Tracing.NewTransport(Metrics.NewTransport(transport, "wegotrip"), "wegotrip"), retry, weGoTripBreaker),
+		weGoTripOptions(settings),
+	)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	healthChecker = newHealthChecker(transport, settings.Health, settings.Upstream)
+	healthChecker.Start(healthCtx)
+
+	runtime, err := buildRuntime(settings, nil)
 	if err != nil {
-		logger.WithError(err).Error("Ошибка создания аффилиатной ссылки")
+		logger.Fatal("Ошибка настройки: ", err)
+	}
+	active.Store(runtime)
+	Metrics.ConfigReloadTime.SetToCurrentTime()
 
-		mc := ManyChat.New()
-		response := mc.FromError(err)
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go watchReload(reloadCtx, settings.Reload.Interval)
 
-		c.JSON(http.StatusOK, response)
-		return
-	}
+	gin.SetMode(gin.ReleaseMode)
 
-	logger.WithField("affiliate_link", affiliateLink).Info("Аффилиатная ссылка создана успешно")
+	r := gin.New()
+	r.Use(requestID, traceRequests)
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		Output:    logger.Out,
+		Formatter: accessLogFormatter,
+	}), gin.Recovery(), observeRequests)
 
-	mc := ManyChat.New()
-	response := mc.FromTravelPayoutsResponse(affiliateLink)
+	r.Use(func(c *gin.Context) {
+		requestLog(c).WithFields(logrus.Fields{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"ip":     c.ClientIP(),
+		}).Info("HTTP Request")
+		c.Next()
+	})
 
-	c.JSON(http.StatusOK, response)
-}
+	api := r.Group("/api")
+	{
+		api.POST("/getFromLink", requireScope(Auth.ScopeGetFromLink), rateLimit("getFromLink"), getFromLink)
+		api.POST("/getFromLinks", requireScope(Auth.ScopeGetFromLink), rateLimit("getFromLinks"), getFromLinks)
+		api.POST("/getFromBrand", requireScope(Auth.ScopeGetFromLink), rateLimit("getFromBrand"), getFromBrand)
+		api.POST("/getFeed", requireScope(Auth.ScopeGetFeed), rateLimit("getFeed"), getFeed)
+		api.GET("/cities", requireScope(Auth.ScopeGetFeed), rateLimit("cities"), listCities)
+		api.GET("/cities/:name", requireScope(Auth.ScopeGetFeed), rateLimit("cities"), getCity)
+	}
 
-func getFeed(c *gin.Context) {
-	var req GetFeedRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.WithError(err).Error("Ошибка валидации запроса getFeed")
+	admin := r.Group("/api/admin", requireScope(Auth.ScopeAdmin))
+	{
+		admin.GET("/keys", listAPIKeys)
+		admin.POST("/keys", createAPIKey)
+		admin.POST("/keys/:id/rotate", rotateAPIKey)
+		admin.DELETE("/keys/:id", revokeAPIKey)
 
-		mc := ManyChat.New()
-		response := mc.FromValidationError("Неверные параметры запроса: " + err.Error())
+		admin.GET("/profiles", listProfiles)
+		admin.PUT("/profiles/:name", saveProfile)
+		admin.DELETE("/profiles/:name", deleteProfile)
 
-		c.JSON(http.StatusOK, response)
-		return
+		admin.POST("/reload", reloadConfig)
 	}
 
-	logger.WithFields(logrus.Fields{
-		"city":     req.City,
-		"lang":     req.Lang,
-		"currency": req.Currency,
-		"page":     req.Page,
-	}).Info("Обработка запроса getFeed")
+	r.GET("/health", func(c *gin.Context) {
+		breakers := make(map[string]modules.BreakerSnapshot, len(upstreamBreakers))
+		for _, breaker := range upstreamBreakers {
+			breakers[breaker.Name()] = breaker.Snapshot()
+		}
+
+		if draining.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":   "draining",
+				"breakers": breakers,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":   "ok",
+			"breakers": breakers,
+		})
+	})
 
-	wg := WeGoTrip.New()
+	r.GET("/health/live", healthLive)
+	r.GET("/health/ready", healthReady)
 
-	feed, err := wg.GetFeed(req.City, req.Lang, req.Currency, req.Page)
-	if err != nil {
-		logger.WithError(err).Error("Ошибка получения данных о поездках")
+	r.GET("/metrics", gin.WrapH(Metrics.Handler()))
 
-		mc := ManyChat.New()
-		response := mc.FromError(err)
+	serve(r, ":"+settings.Server.Port, settings.Server)
+	flushLinkCache()
+}
 
-		c.JSON(http.StatusOK, response)
-		return
+// accessLogFormatter - формат access-лога gin с идентификатором запроса и без значения api_key в строке запроса
+func accessLogFormatter(param gin.LogFormatterParams) string {
+	path := param.Path
+	if strings.Contains(path, "api_key=") {
+		if u, err := url.Parse(path); err == nil {
+			query := u.Query()
+			query.Set("api_key", "[REDACTED]")
+			u.RawQuery = query.Encode()
+			path = u.String()
+		}
 	}
 
-	logger.WithField("feed_length", len(feed)).Info("Данные о поездках получены успешно")
-
-	mc := ManyChat.New()
-	response := mc.FromWeGoGetRespose(feed)
+	requestID, _ := param.Keys[requestIDContextKey].(string)
+
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %s | %-7s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		requestID,
+		param.Method,
+		path,
+		param.ErrorMessage,
+	)
+}
 
-	c.JSON(http.StatusOK, response)
+// requestContext возвращает контекст запроса ManyChat с дедлайном эндпоинта;
+// при обрыве соединения контекст отменяется и запросы к внешним API прерываются
+func requestContext(c *gin.Context, endpoint string) (context.Context, context.CancelFunc) {
+	timeout := current().endpointTimeouts[endpoint]
+	if timeout <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+	return context.WithTimeout(c.Request.Context(), timeout)
 }
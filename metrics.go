@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"tp-go-service/modules/ManyChat"
+	"tp-go-service/modules/Metrics"
+)
+
+// observeRequests учитывает количество и время обработки запросов по маршрутам
+func observeRequests(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	Metrics.HTTPRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+	Metrics.HTTPDuration.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+}
+
+// respond отдает ответ ManyChat и учитывает коды ошибок в нем в метриках и трассировке; при
+// REQUEST_ID_FIELD=true добавляет в ответ идентификатор запроса
+func respond(c *gin.Context, status int, response ManyChat.Response) {
+	errorCodes := response.ErrorCodes()
+	for _, code := range errorCodes {
+		Metrics.APIErrors.WithLabelValues(c.FullPath(), code).Inc()
+	}
+	traceErrorCodes(c, errorCodes)
+
+	if current().config.ManyChat.RequestIDField {
+		if id := c.GetString(requestIDContextKey); id != "" {
+			response = response.WithRequestID(id)
+		}
+	}
+
+	c.JSON(status, response)
+}
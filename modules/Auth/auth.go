@@ -0,0 +1,217 @@
+package Auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"tp-go-service/modules"
+)
+
+const (
+	ScopeGetFromLink = "getFromLink"
+	ScopeGetFeed     = "getFeed"
+	// ScopeProfiles разрешает выбирать в запросе любой профиль учетных данных,
+	// а не только профиль ключа
+	ScopeProfiles = "profiles"
+	ScopeAdmin    = "admin"
+)
+
+// KeyPrefix - префикс выдаваемых ключей, чтобы их было легко узнать в конфигурации
+const KeyPrefix = "tpk_"
+
+// ErrNotFound возвращается хранилищем, если ключ не найден
+var ErrNotFound = errors.New("api key not found")
+
+// Key - API-ключ клиента. Сам ключ не хранится, только его хэш.
+type Key struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	Hint      string     `json:"hint"`
+	Hash      string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	Profile   string     `json:"profile,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active сообщает, действует ли ключ в момент now
+func (k Key) Active(now time.Time) bool {
+	if k.RevokedAt != nil && !now.Before(*k.RevokedAt) {
+		return false
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// HasScope проверяет доступ; admin включает все остальные области
+func (k Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Store - хранилище API-ключей
+type Store interface {
+	CreateAPIKey(key *Key) error
+	FindAPIKeyByHash(hash string) (Key, error)
+	GetAPIKey(id uint) (Key, error)
+	ListAPIKeys() ([]Key, error)
+	UpdateAPIKeyExpiry(id uint, expiresAt *time.Time, revokedAt *time.Time) error
+}
+
+type AuthError struct {
+	modules.BaseError
+}
+
+func NewAuthError(code, message string) modules.APIError {
+	return &AuthError{
+		BaseError: modules.BaseError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+// Keyring проверяет и выдает API-ключи
+type Keyring struct {
+	store     Store
+	masterKey string
+}
+
+// New создает связку ключей; masterKey (если задан) - статический ключ
+// с областью admin, не хранящийся в базе
+func New(store Store, masterKey string) *Keyring {
+	return &Keyring{
+		store:     store,
+		masterKey: masterKey,
+	}
+}
+
+// Authenticate находит действующий ключ по его значению
+func (k *Keyring) Authenticate(secret string) (Key, modules.APIError) {
+	if secret == "" {
+		return Key{}, NewAuthError("unauthorized", "не передан API-ключ")
+	}
+
+	if k.masterKey != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(k.masterKey)) == 1 {
+		return Key{
+			Name:   "master",
+			Scopes: []string{ScopeAdmin},
+		}, nil
+	}
+
+	key, err := k.store.FindAPIKeyByHash(Hash(secret))
+	if err != nil {
+		return Key{}, NewAuthError("invalid_api_key", "неверный API-ключ")
+	}
+
+	if !key.Active(time.Now()) {
+		return Key{}, NewAuthError("invalid_api_key", "API-ключ отозван или истек")
+	}
+
+	return key, nil
+}
+
+// Issue выпускает новый ключ и возвращает его значение (показывается один раз)
+func (k *Keyring) Issue(name string, scopes []string, profile string, expiresAt *time.Time) (Key, string, error) {
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return Key{}, "", errors.New("неизвестная область доступа: " + scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return Key{}, "", errors.New("не указаны области доступа")
+	}
+
+	secret, err := generate()
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	key := Key{
+		Name:      name,
+		Hint:      secret[:len(KeyPrefix)+4],
+		Hash:      Hash(secret),
+		Scopes:    scopes,
+		Profile:   profile,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := k.store.CreateAPIKey(&key); err != nil {
+		return Key{}, "", err
+	}
+
+	return key, secret, nil
+}
+
+// Rotate выпускает ключ с теми же параметрами, а старый ключ продолжает
+// действовать еще grace, чтобы клиенты успели переключиться
+func (k *Keyring) Rotate(id uint, grace time.Duration) (Key, string, error) {
+	old, err := k.store.GetAPIKey(id)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	key, secret, err := k.Issue(old.Name, old.Scopes, old.Profile, old.ExpiresAt)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	expiresAt := time.Now().UTC().Add(grace)
+	if old.ExpiresAt != nil && old.ExpiresAt.Before(expiresAt) {
+		expiresAt = *old.ExpiresAt
+	}
+	if err := k.store.UpdateAPIKeyExpiry(id, &expiresAt, old.RevokedAt); err != nil {
+		return Key{}, "", err
+	}
+
+	return key, secret, nil
+}
+
+// Revoke немедленно отзывает ключ
+func (k *Keyring) Revoke(id uint) error {
+	old, err := k.store.GetAPIKey(id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return k.store.UpdateAPIKeyExpiry(id, old.ExpiresAt, &now)
+}
+
+func (k *Keyring) List() ([]Key, error) {
+	return k.store.ListAPIKeys()
+}
+
+func ValidScope(scope string) bool {
+	switch scope {
+	case ScopeGetFromLink, ScopeGetFeed, ScopeProfiles, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// Hash возвращает хэш значения ключа для хранения и поиска
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func generate() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return KeyPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,224 @@
+package Brands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"tp-go-service/modules"
+)
+
+type Brand struct {
+	Name    string   `json:"name" yaml:"name"`
+	Aliases []string `json:"aliases" yaml:"aliases"`
+	URL     string   `json:"url" yaml:"url"`
+	Enabled bool     `json:"enabled" yaml:"enabled"`
+}
+
+type BrandsError struct {
+	modules.BaseError
+}
+
+func NewBrandsError(code, message string) modules.APIError {
+	return &BrandsError{
+		BaseError: modules.BaseError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+// DefaultBrands - бренды, доступные без дополнительной настройки
+var DefaultBrands = []Brand{
+	{
+		Name:    "booking",
+		Aliases: []string{"booking.com", "букинг"},
+		URL:     "https://www.booking.com",
+		Enabled: true,
+	},
+	{
+		Name:    "agoda",
+		Aliases: []string{"agoda.com", "агода"},
+		URL:     "https://www.agoda.com",
+		Enabled: true,
+	},
+	{
+		Name:    "aviasales",
+		Aliases: []string{"aviasales.com", "aviasales.ru", "авиасейлс"},
+		URL:     "https://aviasales.com",
+		Enabled: true,
+	},
+	{
+		Name:    "hotels",
+		Aliases: []string{"hotels.com", "хотелс"},
+		URL:     "https://hotels.com",
+		Enabled: true,
+	},
+	{
+		Name:    "expedia",
+		Aliases: []string{"expedia.com", "экспедия"},
+		URL:     "https://www.expedia.com",
+		Enabled: true,
+	},
+}
+
+type Catalog struct {
+	mu     sync.RWMutex
+	brands map[string]Brand
+	index  map[string]string
+}
+
+func New(brands []Brand) *Catalog {
+	catalog := &Catalog{}
+	catalog.Replace(brands)
+	return catalog
+}
+
+func NewDefault() *Catalog {
+	return New(DefaultBrands)
+}
+
+// Replace атомарно заменяет содержимое каталога
+func (c *Catalog) Replace(brands []Brand) {
+	byName := make(map[string]Brand, len(brands))
+	index := make(map[string]string, len(brands))
+
+	for _, brand := range brands {
+		name := normalize(brand.Name)
+		if name == "" {
+			continue
+		}
+		byName[name] = brand
+		index[name] = name
+		for _, alias := range brand.Aliases {
+			if alias := normalize(alias); alias != "" {
+				index[alias] = name
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.brands = byName
+	c.index = index
+	c.mu.Unlock()
+}
+
+// Resolve находит включенный бренд по названию или алиасу
+func (c *Catalog) Resolve(brandName string) (Brand, modules.APIError) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	name, ok := c.index[normalize(brandName)]
+	if !ok {
+		return Brand{}, NewBrandsError("brand_not_found", "бренд не найден: "+brandName)
+	}
+
+	brand := c.brands[name]
+	if !brand.Enabled {
+		return Brand{}, NewBrandsError("brand_not_found", "бренд отключен: "+brandName)
+	}
+
+	if brand.URL == "" {
+		return Brand{}, NewBrandsError("brand_not_found", "у бренда не задана ссылка: "+brandName)
+	}
+
+	return brand, nil
+}
+
+// List возвращает все бренды каталога, отсортированные по названию
+func (c *Catalog) List() []Brand {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	brands := make([]Brand, 0, len(c.brands))
+	for _, brand := range c.brands {
+		brands = append(brands, brand)
+	}
+
+	sort.Slice(brands, func(i, j int) bool {
+		return brands[i].Name < brands[j].Name
+	})
+
+	return brands
+}
+
+// fileBrand - бренд в YAML-файле; без enabled бренд считается включенным
+type fileBrand struct {
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+	URL     string   `yaml:"url"`
+	Enabled *bool    `yaml:"enabled"`
+}
+
+// LoadFile читает список брендов из YAML-файла
+func LoadFile(path string) ([]Brand, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []fileBrand
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&list); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	brands := make([]Brand, 0, len(list))
+	var errs []error
+	for i, item := range list {
+		if normalize(item.Name) == "" {
+			errs = append(errs, fmt.Errorf("[%d]: не задано название бренда", i))
+			continue
+		}
+		if item.URL != "" {
+			if u, err := url.Parse(item.URL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("%s: неверная ссылка %q", item.Name, item.URL))
+				continue
+			}
+		}
+
+		brands = append(brands, Brand{
+			Name:    item.Name,
+			Aliases: item.Aliases,
+			URL:     item.URL,
+			Enabled: item.Enabled == nil || *item.Enabled,
+		})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return brands, nil
+}
+
+// Merge возвращает бренды base, замененные и дополненные брендами overrides
+// с тем же названием
+func Merge(base, overrides []Brand) []Brand {
+	merged := make([]Brand, 0, len(base)+len(overrides))
+	position := make(map[string]int, len(base)+len(overrides))
+
+	for _, brand := range append(append([]Brand{}, base...), overrides...) {
+		name := normalize(brand.Name)
+		if i, ok := position[name]; ok {
+			merged[i] = brand
+			continue
+		}
+		position[name] = len(merged)
+		merged = append(merged, brand)
+	}
+
+	return merged
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,74 @@
+package Cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Key - ключ кэша аффилиатной ссылки
+type Key struct {
+	URL     string
+	TRS     string
+	Marker  string
+	SubID   string
+	Shorten bool
+}
+
+// LinkCache - кэш партнерских ссылок
+type LinkCache interface {
+	Get(key Key) (string, bool)
+	Set(key Key, partnerURL string)
+}
+
+// Flusher - кэш, которому нужно сохранить состояние перед остановкой сервиса
+type Flusher interface {
+	Flush() error
+}
+
+// NewKey создает ключ с нормализованной исходной ссылкой
+func NewKey(link, trs, marker, subID string, shorten bool) Key {
+	return Key{
+		URL:     NormalizeURL(link),
+		TRS:     strings.TrimSpace(trs),
+		Marker:  strings.TrimSpace(marker),
+		SubID:   subID,
+		Shorten: shorten,
+	}
+}
+
+// String возвращает компактное представление ключа для хранения
+func (k Key) String() string {
+	sum := sha256.Sum256([]byte(k.URL + "\x00" + k.TRS + "\x00" + k.Marker + "\x00" + k.SubID + "\x00" + strconv.FormatBool(k.Shorten)))
+	return hex.EncodeToString(sum[:])
+}
+
+// NormalizeURL приводит ссылку к каноническому виду: схема и хост в нижнем
+// регистре, без портов по умолчанию, с отсортированными параметрами запроса
+func NormalizeURL(link string) string {
+	link = strings.TrimSpace(link)
+
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = u.Hostname()
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+
+	return u.String()
+}
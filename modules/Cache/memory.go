@@ -0,0 +1,93 @@
+package Cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key        Key
+	partnerURL string
+	expiresAt  time.Time
+}
+
+// Memory - LRU кэш в памяти с ограничением по времени жизни и размеру
+type Memory struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[Key]*list.Element
+	order      *list.List
+}
+
+func NewMemory(ttl time.Duration, maxEntries int) *Memory {
+	return &Memory{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[Key]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (m *Memory) Get(key Key) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.remove(element)
+		return "", false
+	}
+
+	m.order.MoveToFront(element)
+	return entry.partnerURL, true
+}
+
+func (m *Memory) Set(key Key, partnerURL string) {
+	m.setUntil(key, partnerURL, time.Now().Add(m.ttl))
+}
+
+func (m *Memory) setUntil(key Key, partnerURL string, expiresAt time.Time) {
+	if m.maxEntries <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.partnerURL = partnerURL
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{
+		key:        key,
+		partnerURL: partnerURL,
+		expiresAt:  expiresAt,
+	})
+
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+}
+
+// Len возвращает количество записей, включая еще не вытесненные устаревшие
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) remove(element *list.Element) {
+	entry := m.order.Remove(element).(*memoryEntry)
+	delete(m.entries, entry.key)
+}
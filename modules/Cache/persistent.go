@@ -0,0 +1,84 @@
+package Cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// pruneEvery - как часто (в записях) проверять ограничение размера хранилища
+const pruneEvery = 64
+
+// Store - хранилище записей постоянного кэша
+type Store interface {
+	GetCachedLink(key string, now time.Time) (partnerURL string, expiresAt time.Time, found bool, err error)
+	PutCachedLink(key, originalURL, trs, marker, subID string, shorten bool, partnerURL string, expiresAt time.Time) error
+	PruneCachedLinks(now time.Time, maxEntries int) error
+}
+
+// Persistent - кэш в базе данных с LRU кэшем в памяти перед ним
+type Persistent struct {
+	store      Store
+	memory     *Memory
+	ttl        time.Duration
+	maxEntries int
+	writes     atomic.Int64
+
+	// OnError вызывается при ошибках хранилища; сами ошибки считаются промахом кэша
+	OnError func(err error)
+}
+
+func NewPersistent(store Store, ttl time.Duration, maxEntries, memoryEntries int) *Persistent {
+	return &Persistent{
+		store:      store,
+		memory:     NewMemory(ttl, memoryEntries),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+func (p *Persistent) Get(key Key) (string, bool) {
+	if partnerURL, ok := p.memory.Get(key); ok {
+		return partnerURL, true
+	}
+
+	partnerURL, expiresAt, found, err := p.store.GetCachedLink(key.String(), time.Now())
+	if err != nil {
+		p.handleError(err)
+		return "", false
+	}
+	if !found {
+		return "", false
+	}
+
+	p.memory.setUntil(key, partnerURL, expiresAt)
+	return partnerURL, true
+}
+
+func (p *Persistent) Set(key Key, partnerURL string) {
+	expiresAt := time.Now().Add(p.ttl)
+	p.memory.setUntil(key, partnerURL, expiresAt)
+
+	err := p.store.PutCachedLink(key.String(), key.URL, key.TRS, key.Marker, key.SubID, key.Shorten, partnerURL, expiresAt)
+	if err != nil {
+		p.handleError(err)
+		return
+	}
+
+	if p.writes.Add(1)%pruneEvery == 0 {
+		if err := p.store.PruneCachedLinks(time.Now(), p.maxEntries); err != nil {
+			p.handleError(err)
+		}
+	}
+}
+
+// Flush удаляет устаревшие записи и лишние сверх ограничения размера; записи
+// кэша попадают в базу сразу, поэтому больше сохранять нечего
+func (p *Persistent) Flush() error {
+	return p.store.PruneCachedLinks(time.Now(), p.maxEntries)
+}
+
+func (p *Persistent) handleError(err error) {
+	if p.OnError != nil {
+		p.OnError(err)
+	}
+}
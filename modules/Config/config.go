@@ -0,0 +1,436 @@
+package Config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules"
+	"tp-go-service/modules/RateLimit"
+	"tp-go-service/modules/Storage"
+	"tp-go-service/modules/Tracing"
+	"tp-go-service/modules/TravelPayouts"
+)
+
+// Config - настройки сервиса. Значения по умолчанию совпадают с прежним
+// поведением; файл конфигурации и переменные окружения (тег env) их переопределяют.
+// Поля с тегом secret:"true" скрываются в --print-config.
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Log       LogConfig       `yaml:"log"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Profiles  ProfilesConfig  `yaml:"profiles"`
+	Links     LinksConfig     `yaml:"links"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Upstream  UpstreamConfig  `yaml:"upstream"`
+	Feed      FeedConfig      `yaml:"feed"`
+	ManyChat  ManyChatConfig  `yaml:"manychat"`
+	Timeouts  TimeoutsConfig  `yaml:"timeouts"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Health    HealthConfig    `yaml:"health"`
+	Data      DataConfig      `yaml:"data"`
+	Reload    ReloadConfig    `yaml:"reload"`
+}
+
+type ServerConfig struct {
+	Port              string        `yaml:"port" env:"PORT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// DrainDelay - сколько после сигнала сервер еще принимает запросы, отвечая
+	// на /health "draining", чтобы балансировщик успел убрать его из ротации
+	DrainDelay time.Duration `yaml:"drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	// ShutdownTimeout - сколько ждать завершения начатых запросов
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+}
+
+type DatabaseConfig struct {
+	Path string `yaml:"path" env:"DB_PATH"`
+}
+
+type AuthConfig struct {
+	// Enabled - проверять ключи для /api; административные маршруты проверяются всегда
+	Enabled     bool   `yaml:"enabled" env:"AUTH_ENABLED"`
+	AdminAPIKey string `yaml:"admin_api_key" env:"ADMIN_API_KEY" secret:"true"`
+}
+
+type ProfilesConfig struct {
+	Default string `yaml:"default" env:"DEFAULT_PROFILE"`
+	Secret  string `yaml:"secret" env:"PROFILES_SECRET" secret:"true"`
+}
+
+type LinksConfig struct {
+	SubIDTemplate        string            `yaml:"sub_id_template" env:"SUB_ID_TEMPLATE"`
+	SubIDTemplates       map[string]string `yaml:"sub_id_templates" env:"SUB_ID_TEMPLATES"`
+	FallbackOriginalLink bool              `yaml:"fallback_original_link" env:"FALLBACK_ORIGINAL_LINK"`
+}
+
+type CacheConfig struct {
+	TTL        time.Duration `yaml:"ttl" env:"LINK_CACHE_TTL"`
+	Size       int           `yaml:"size" env:"LINK_CACHE_SIZE"`
+	MemorySize int           `yaml:"memory_size" env:"LINK_CACHE_MEMORY_SIZE"`
+	Persistent bool          `yaml:"persistent" env:"LINK_CACHE_PERSISTENT"`
+}
+
+type UpstreamConfig struct {
+	TravelPayoutsURL string          `yaml:"travelpayouts_url" env:"TRAVELPAYOUTS_BASE_URL"`
+	WeGoTripCOMURL   string          `yaml:"wegotrip_com_url" env:"WEGOTRIP_COM_BASE_URL"`
+	WeGoTripRUURL    string          `yaml:"wegotrip_ru_url" env:"WEGOTRIP_RU_BASE_URL"`
+	Retry            RetryConfig     `yaml:"retry"`
+	Transport        TransportConfig `yaml:"transport"`
+	Breaker          BreakerConfig   `yaml:"breaker"`
+}
+
+type RetryConfig struct {
+	Attempts      int           `yaml:"attempts" env:"UPSTREAM_RETRY_ATTEMPTS"`
+	BaseDelay     time.Duration `yaml:"base_delay" env:"UPSTREAM_RETRY_BASE_DELAY"`
+	MaxDelay      time.Duration `yaml:"max_delay" env:"UPSTREAM_RETRY_MAX_DELAY"`
+	Jitter        float64       `yaml:"jitter" env:"UPSTREAM_RETRY_JITTER"`
+	Statuses      []int         `yaml:"statuses" env:"UPSTREAM_RETRY_STATUSES"`
+	Deadline      time.Duration `yaml:"deadline" env:"UPSTREAM_DEADLINE"`
+	ClientTimeout time.Duration `yaml:"client_timeout" env:"UPSTREAM_CLIENT_TIMEOUT"`
+}
+
+type TransportConfig struct {
+	MaxIdleConns        int           `yaml:"max_idle_conns" env:"UPSTREAM_MAX_IDLE_CONNS"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" env:"UPSTREAM_MAX_IDLE_CONNS_PER_HOST"`
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host" env:"UPSTREAM_MAX_CONNS_PER_HOST"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" env:"UPSTREAM_IDLE_CONN_TIMEOUT"`
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout" env:"UPSTREAM_TLS_HANDSHAKE_TIMEOUT"`
+	DialTimeout         time.Duration `yaml:"dial_timeout" env:"UPSTREAM_DIAL_TIMEOUT"`
+}
+
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold" env:"BREAKER_FAILURE_THRESHOLD"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" env:"BREAKER_OPEN_TIMEOUT"`
+	HalfOpenRequests int           `yaml:"half_open_requests" env:"BREAKER_HALF_OPEN_REQUESTS"`
+}
+
+type FeedConfig struct {
+	PageSize    int `yaml:"page_size" env:"FEED_PAGE_SIZE"`
+	MaxPageSize int `yaml:"max_page_size" env:"FEED_MAX_PAGE_SIZE"`
+	// CityMinSimilarity - наименьшее сходство названия города при поиске с опечатками
+	CityMinSimilarity float64 `yaml:"city_min_similarity" env:"FEED_CITY_MIN_SIMILARITY"`
+	// CitySuggestions - сколько похожих городов предлагать при city_not_found; 0 - не предлагать
+	CitySuggestions int    `yaml:"city_suggestions" env:"FEED_CITY_SUGGESTIONS"`
+	DefaultLang     string `yaml:"default_lang" env:"FEED_DEFAULT_LANG"`
+	DefaultCurrency string `yaml:"default_currency" env:"FEED_DEFAULT_CURRENCY"`
+	// COMSiteURL и RUSiteURL - публичные сайты, на которые ведут ссылки экскурсий;
+	// не зависят от адресов API в upstream
+	COMSiteURL string `yaml:"com_site_url" env:"FEED_COM_SITE_URL"`
+	RUSiteURL  string `yaml:"ru_site_url" env:"FEED_RU_SITE_URL"`
+}
+
+type ManyChatConfig struct {
+	Version        string `yaml:"version" env:"MANYCHAT_VERSION"`
+	ContentType    string `yaml:"content_type" env:"MANYCHAT_CONTENT_TYPE"`
+	RequestIDField bool   `yaml:"request_id_field" env:"REQUEST_ID_FIELD"`
+}
+
+// TimeoutsConfig - дедлайны эндпоинтов; 0 - дедлайн повторов upstream.retry.deadline
+type TimeoutsConfig struct {
+	GetFromLink  time.Duration `yaml:"get_from_link" env:"GET_FROM_LINK_TIMEOUT"`
+	GetFromLinks time.Duration `yaml:"get_from_links" env:"GET_FROM_LINKS_TIMEOUT"`
+	GetFromBrand time.Duration `yaml:"get_from_brand" env:"GET_FROM_BRAND_TIMEOUT"`
+	GetFeed      time.Duration `yaml:"get_feed" env:"GET_FEED_TIMEOUT"`
+}
+
+// RateLimitConfig - лимиты вида "60/m" или "10/s,burst=20"; "0" выключает
+// ограничение, пустой лимит маршрута - лимит по умолчанию
+type RateLimitConfig struct {
+	Default                string `yaml:"default" env:"RATE_LIMIT_DEFAULT"`
+	SubscriberDefault      string `yaml:"subscriber_default" env:"RATE_LIMIT_SUBSCRIBER_DEFAULT"`
+	GetFromLink            string `yaml:"get_from_link" env:"RATE_LIMIT_GET_FROM_LINK"`
+	GetFromLinkSubscriber  string `yaml:"get_from_link_subscriber" env:"RATE_LIMIT_GET_FROM_LINK_SUBSCRIBER"`
+	GetFromLinks           string `yaml:"get_from_links" env:"RATE_LIMIT_GET_FROM_LINKS"`
+	GetFromLinksSubscriber string `yaml:"get_from_links_subscriber" env:"RATE_LIMIT_GET_FROM_LINKS_SUBSCRIBER"`
+	GetFromBrand           string `yaml:"get_from_brand" env:"RATE_LIMIT_GET_FROM_BRAND"`
+	GetFromBrandSubscriber string `yaml:"get_from_brand_subscriber" env:"RATE_LIMIT_GET_FROM_BRAND_SUBSCRIBER"`
+	GetFeed                string `yaml:"get_feed" env:"RATE_LIMIT_GET_FEED"`
+	GetFeedSubscriber      string `yaml:"get_feed_subscriber" env:"RATE_LIMIT_GET_FEED_SUBSCRIBER"`
+	Cities                 string `yaml:"cities" env:"RATE_LIMIT_CITIES"`
+}
+
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"TRACING_ENABLED"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+}
+
+type HealthConfig struct {
+	ProbeInterval time.Duration `yaml:"probe_interval" env:"HEALTH_PROBE_INTERVAL"`
+	ProbeTimeout  time.Duration `yaml:"probe_timeout" env:"HEALTH_PROBE_TIMEOUT"`
+	// UpstreamsCritical - недоступность Travelpayouts или WeGoTrip делает сервис неготовым
+	UpstreamsCritical bool `yaml:"upstreams_critical" env:"HEALTH_UPSTREAMS_CRITICAL"`
+}
+
+// DataConfig - файлы данных, которые дополняют встроенные каталоги
+type DataConfig struct {
+	// CitiesFile - YAML с городами WeGoTrip поверх встроенных таблиц
+	CitiesFile string `yaml:"cities_file" env:"CITIES_FILE"`
+	// BrandsFile - YAML с брендами поверх каталога из базы
+	BrandsFile string `yaml:"brands_file" env:"BRANDS_FILE"`
+}
+
+// ReloadConfig - перечитывание конфигурации и файлов данных без перезапуска
+type ReloadConfig struct {
+	// Interval - период проверки изменений файлов; 0 - только по SIGHUP и через API
+	Interval time.Duration `yaml:"interval" env:"RELOAD_INTERVAL"`
+}
+
+// Default возвращает настройки, с которыми сервис работал до появления конфигурации
+func Default() Config {
+	retry := modules.DefaultRetryConfig()
+	transport := modules.DefaultTransportConfig()
+	breaker := modules.DefaultBreakerConfig()
+	tracing := Tracing.DefaultConfig()
+
+	return Config{
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Database: DatabaseConfig{
+			Path: Storage.DefaultPath,
+		},
+		Links: LinksConfig{
+			SubIDTemplate: TravelPayouts.DefaultSubID,
+		},
+		Cache: CacheConfig{
+			TTL:        24 * time.Hour,
+			Size:       10000,
+			MemorySize: 1000,
+			Persistent: true,
+		},
+		Upstream: UpstreamConfig{
+			TravelPayoutsURL: "https://api.travelpayouts.com",
+			WeGoTripCOMURL:   "https://app.wegotrip.com",
+			WeGoTripRUURL:    "https://wegotrip.ru",
+			Retry: RetryConfig{
+				Attempts:      retry.MaxAttempts,
+				BaseDelay:     retry.BaseDelay,
+				MaxDelay:      retry.MaxDelay,
+				Jitter:        retry.Jitter,
+				Statuses:      retry.RetryOn,
+				Deadline:      retry.Deadline,
+				ClientTimeout: retry.ClientTimeout,
+			},
+			Transport: TransportConfig{
+				MaxIdleConns:        transport.MaxIdleConns,
+				MaxIdleConnsPerHost: transport.MaxIdleConnsPerHost,
+				MaxConnsPerHost:     transport.MaxConnsPerHost,
+				IdleConnTimeout:     transport.IdleConnTimeout,
+				TLSHandshakeTimeout: transport.TLSHandshakeTimeout,
+				DialTimeout:         transport.DialTimeout,
+			},
+			Breaker: BreakerConfig{
+				FailureThreshold: breaker.FailureThreshold,
+				OpenTimeout:      breaker.OpenTimeout,
+				HalfOpenRequests: breaker.HalfOpenMaxRequests,
+			},
+		},
+		Feed: FeedConfig{
+			PageSize:          3,
+			MaxPageSize:       10,
+			CityMinSimilarity: 0.75,
+			CitySuggestions:   3,
+			DefaultLang:       "RU",
+			DefaultCurrency:   "RUB",
+			COMSiteURL:        "https://app.wegotrip.com",
+			RUSiteURL:         "https://wegotrip.ru",
+		},
+		ManyChat: ManyChatConfig{
+			Version:     "v2",
+			ContentType: "instagram",
+		},
+		RateLimit: RateLimitConfig{
+			Default:           "600/m",
+			SubscriberDefault: "30/m",
+		},
+		Tracing: TracingConfig{
+			Enabled:     tracing.Enabled,
+			ServiceName: tracing.ServiceName,
+			SampleRatio: tracing.SampleRatio,
+		},
+		Health: HealthConfig{
+			ProbeInterval: 30 * time.Second,
+			ProbeTimeout:  5 * time.Second,
+		},
+		Reload: ReloadConfig{
+			Interval: 10 * time.Second,
+		},
+	}
+}
+
+// Validate проверяет настройки и возвращает все найденные ошибки сразу
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port != "", "server.port: не задан")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout: не может быть отрицательным")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout: не может быть отрицательным")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: должен быть больше 0")
+	check(c.Server.DrainDelay >= 0, "server.drain_delay: не может быть отрицательным")
+
+	_, err := logrus.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: неизвестный уровень %q", c.Log.Level)
+
+	check(c.Database.Path != "", "database.path: не задан")
+
+	check(c.Cache.TTL > 0, "cache.ttl: должен быть больше 0")
+	check(c.Cache.Size > 0, "cache.size: должен быть больше 0")
+	check(c.Cache.MemorySize > 0, "cache.memory_size: должен быть больше 0")
+
+	for _, u := range []struct{ name, value string }{
+		{"upstream.travelpayouts_url", c.Upstream.TravelPayoutsURL},
+		{"upstream.wegotrip_com_url", c.Upstream.WeGoTripCOMURL},
+		{"upstream.wegotrip_ru_url", c.Upstream.WeGoTripRUURL},
+		{"feed.com_site_url", c.Feed.COMSiteURL},
+		{"feed.ru_site_url", c.Feed.RUSiteURL},
+	} {
+		parsed, err := url.Parse(u.value)
+		check(err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "", "%s: неверный адрес %q", u.name, u.value)
+	}
+
+	retry := c.Upstream.Retry
+	check(retry.Attempts >= 1, "upstream.retry.attempts: должно быть не меньше 1")
+	check(retry.BaseDelay >= 0 && retry.MaxDelay >= retry.BaseDelay, "upstream.retry: max_delay должен быть не меньше base_delay")
+	check(retry.Jitter >= 0 && retry.Jitter <= 1, "upstream.retry.jitter: должен быть от 0 до 1")
+	check(retry.Deadline > 0, "upstream.retry.deadline: должен быть больше 0")
+	check(retry.ClientTimeout > 0, "upstream.retry.client_timeout: должен быть больше 0")
+	for _, status := range retry.Statuses {
+		check(status >= 100 && status <= 599, "upstream.retry.statuses: неверный HTTP-статус %d", status)
+	}
+
+	check(c.Upstream.Breaker.FailureThreshold >= 1, "upstream.breaker.failure_threshold: должно быть не меньше 1")
+	check(c.Upstream.Breaker.OpenTimeout > 0, "upstream.breaker.open_timeout: должен быть больше 0")
+	check(c.Upstream.Breaker.HalfOpenRequests >= 1, "upstream.breaker.half_open_requests: должно быть не меньше 1")
+
+	check(c.Feed.PageSize >= 1, "feed.page_size: должен быть не меньше 1")
+	check(c.Feed.MaxPageSize >= c.Feed.PageSize, "feed.max_page_size: должен быть не меньше feed.page_size")
+	check(c.Feed.CitySuggestions >= 0 && c.Feed.CitySuggestions <= 10, "feed.city_suggestions: должно быть от 0 до 10")
+	check(c.Feed.CityMinSimilarity > 0 && c.Feed.CityMinSimilarity <= 1, "feed.city_min_similarity: должно быть больше 0 и не больше 1")
+	check(c.Feed.DefaultLang != "", "feed.default_lang: не задан")
+	check(c.Feed.DefaultCurrency != "", "feed.default_currency: не задана")
+
+	check(c.ManyChat.Version != "", "manychat.version: не задана")
+	check(c.ManyChat.ContentType != "", "manychat.content_type: не задан")
+
+	for _, t := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"timeouts.get_from_link", c.Timeouts.GetFromLink},
+		{"timeouts.get_from_links", c.Timeouts.GetFromLinks},
+		{"timeouts.get_from_brand", c.Timeouts.GetFromBrand},
+		{"timeouts.get_feed", c.Timeouts.GetFeed},
+	} {
+		check(t.timeout >= 0, "%s: не может быть отрицательным", t.name)
+		check(c.Server.WriteTimeout == 0 || c.EndpointTimeout(t.timeout) < c.Server.WriteTimeout, "%s: должен быть меньше server.write_timeout", t.name)
+	}
+
+	for _, limit := range c.RateLimit.limits() {
+		if limit.value == "" {
+			continue
+		}
+		_, err := RateLimit.ParseLimit(limit.value)
+		check(err == nil, "rate_limit.%s: %v", limit.name, err)
+	}
+
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: должна быть от 0 до 1")
+
+	check(c.Health.ProbeInterval > 0, "health.probe_interval: должен быть больше 0")
+	check(c.Health.ProbeTimeout > 0, "health.probe_timeout: должен быть больше 0")
+
+	check(c.Reload.Interval >= 0, "reload.interval: не может быть отрицательным")
+
+	return errors.Join(errs...)
+}
+
+// KeepStatic переносит из running настройки, которые применяются только при
+// запуске, и возвращает имена разделов, изменение которых требует перезапуска
+func (c *Config) KeepStatic(running Config) []string {
+	changed := []string{}
+	keep := func(name string, next, current any, restore func()) {
+		if !reflect.DeepEqual(next, current) {
+			changed = append(changed, name)
+			restore()
+		}
+	}
+
+	keep("server", c.Server, running.Server, func() { c.Server = running.Server })
+	keep("database", c.Database, running.Database, func() { c.Database = running.Database })
+	keep("auth.admin_api_key", c.Auth.AdminAPIKey, running.Auth.AdminAPIKey, func() { c.Auth.AdminAPIKey = running.Auth.AdminAPIKey })
+	keep("profiles", c.Profiles, running.Profiles, func() { c.Profiles = running.Profiles })
+	keep("cache", c.Cache, running.Cache, func() { c.Cache = running.Cache })
+	keep("upstream", c.Upstream, running.Upstream, func() { c.Upstream = running.Upstream })
+	keep("tracing", c.Tracing, running.Tracing, func() { c.Tracing = running.Tracing })
+	keep("health", c.Health, running.Health, func() { c.Health = running.Health })
+	keep("reload", c.Reload, running.Reload, func() { c.Reload = running.Reload })
+
+	return changed
+}
+
+// Diff возвращает разделы (по ключам YAML), которые отличаются от previous
+func (c Config) Diff(previous Config) []string {
+	next, old := reflect.ValueOf(c), reflect.ValueOf(previous)
+
+	changed := []string{}
+	for i := 0; i < next.NumField(); i++ {
+		if !reflect.DeepEqual(next.Field(i).Interface(), old.Field(i).Interface()) {
+			name, _, _ := strings.Cut(next.Type().Field(i).Tag.Get("yaml"), ",")
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// EndpointTimeout возвращает дедлайн эндпоинта с учетом значения по умолчанию
+func (c Config) EndpointTimeout(timeout time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	return c.Upstream.Retry.Deadline
+}
+
+func (r RateLimitConfig) limits() []struct{ name, value string } {
+	return []struct{ name, value string }{
+		{"default", r.Default},
+		{"subscriber_default", r.SubscriberDefault},
+		{"get_from_link", r.GetFromLink},
+		{"get_from_link_subscriber", r.GetFromLinkSubscriber},
+		{"get_from_links", r.GetFromLinks},
+		{"get_from_links_subscriber", r.GetFromLinksSubscriber},
+		{"get_from_brand", r.GetFromBrand},
+		{"get_from_brand_subscriber", r.GetFromBrandSubscriber},
+		{"get_feed", r.GetFeed},
+		{"get_feed_subscriber", r.GetFeedSubscriber},
+		{"cities", r.Cities},
+	}
+}
@@ -0,0 +1,159 @@
+package Config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load собирает настройки: значения по умолчанию, затем файл path (если
+// задан), затем переменные окружения environ. Неизвестные ключи файла и
+// неразбираемые значения переменных считаются ошибкой.
+func Load(path string, environ []string) (Config, error) {
+	config := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("чтение файла конфигурации: %w", err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return Config{}, fmt.Errorf("разбор файла конфигурации %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(&config).Elem(), envMap(environ)); err != nil {
+		return Config{}, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("неверная конфигурация:\n%w", err)
+	}
+
+	return config, nil
+}
+
+func envMap(environ []string) map[string]string {
+	values := make(map[string]string, len(environ))
+	for _, pair := range environ {
+		if name, value, ok := strings.Cut(pair, "="); ok {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv записывает в поля с тегом env значения заданных переменных окружения
+func applyEnv(v reflect.Value, env map[string]string) error {
+	var errs []error
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		structField := v.Type().Field(i)
+
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			if err := applyEnv(field, env); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		name := structField.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		value, ok := env[name]
+		if !ok {
+			continue
+		}
+
+		if err := setField(field, strings.TrimSpace(value)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Int:
+		// список через запятую: "429,502,503"
+		var items []int
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			n, err := strconv.Atoi(item)
+			if err != nil {
+				return err
+			}
+			items = append(items, n)
+		}
+		field.Set(reflect.ValueOf(items))
+
+	case field.Kind() == reflect.Map && field.Type().Elem().Kind() == reflect.String:
+		// пары через точку с запятой: "name=value;name2=value2"
+		items := make(map[string]string)
+		for _, pair := range strings.Split(value, ";") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			name, item, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("ожидается формат name=value, получено %q", pair)
+			}
+			items[strings.TrimSpace(name)] = strings.TrimSpace(item)
+		}
+		field.Set(reflect.ValueOf(items))
+
+	default:
+		return fmt.Errorf("неподдерживаемый тип поля %s", field.Type())
+	}
+
+	return nil
+}
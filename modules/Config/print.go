@@ -0,0 +1,73 @@
+package Config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted - замена значений секретных полей
+const redacted = "[REDACTED]"
+
+// Redacted возвращает настройки в YAML в порядке полей, без значений
+// секретов и с длительностями в виде "30s"
+func (c Config) Redacted() ([]byte, error) {
+	node, err := toNode(reflect.ValueOf(c), false)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func toNode(v reflect.Value, secret bool) (*yaml.Node, error) {
+	node := &yaml.Node{}
+
+	switch {
+	case secret:
+		value := ""
+		if !v.IsZero() {
+			value = redacted
+		}
+		node.Kind = yaml.ScalarNode
+		node.Tag = "!!str"
+		node.Value = value
+
+	case v.Type() == durationType:
+		node.Kind = yaml.ScalarNode
+		node.Value = v.Interface().(interface{ String() string }).String()
+
+	case v.Kind() == reflect.Struct:
+		node.Kind = yaml.MappingNode
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			value, err := toNode(v.Field(i), field.Tag.Get("secret") == "true")
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+		}
+
+	default:
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
@@ -0,0 +1,186 @@
+package Health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status - состояние зависимости или сервиса целиком
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+	// StatusPending - периодическая проверка еще ни разу не выполнялась
+	StatusPending Status = "pending"
+)
+
+// Check - проверка одной зависимости
+type Check struct {
+	Name string
+	// Critical - при отказе сервис не готов принимать запросы;
+	// отказ некритичной зависимости означает "degraded"
+	Critical bool
+	// Interval - период фоновой проверки; 0 - проверка на каждый запрос готовности
+	Interval time.Duration
+	Timeout  time.Duration
+	Probe    func(ctx context.Context) error
+}
+
+// Result - последний результат проверки
+type Result struct {
+	Status      Status     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LatencyMS   float64    `json:"latency_ms"`
+	LastError   string     `json:"last_error,omitempty"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+}
+
+// Report - состояние всех зависимостей
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready сообщает, что все критичные зависимости доступны
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// Checker выполняет проверки зависимостей; результаты периодических проверок
+// кэшируются, чтобы частые запросы готовности не нагружали внешние API
+type Checker struct {
+	checks []Check
+
+	mu      sync.RWMutex
+	results map[string]Result
+}
+
+// defaultTimeout - таймаут проверки без явного Timeout
+const defaultTimeout = 5 * time.Second
+
+func New(checks ...Check) *Checker {
+	results := make(map[string]Result, len(checks))
+	for _, check := range checks {
+		results[check.Name] = Result{Status: StatusPending, Critical: check.Critical}
+	}
+
+	return &Checker{
+		checks:  checks,
+		results: results,
+	}
+}
+
+// Start запускает фоновые проверки до отмены ctx; первая проверка выполняется сразу
+func (c *Checker) Start(ctx context.Context) {
+	for _, check := range c.checks {
+		if check.Interval <= 0 {
+			continue
+		}
+
+		go func(check Check) {
+			ticker := time.NewTicker(check.Interval)
+			defer ticker.Stop()
+
+			for {
+				c.run(ctx, check)
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(check)
+	}
+}
+
+// Check выполняет проверки без периода и возвращает состояние всех зависимостей
+func (c *Checker) Check(ctx context.Context) Report {
+	for _, check := range c.checks {
+		if check.Interval <= 0 {
+			c.run(ctx, check)
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(c.results)),
+	}
+
+	for name, result := range c.results {
+		report.Checks[name] = result
+
+		switch {
+		case result.Status == StatusOK:
+		case result.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Probe(probeCtx)
+	latency := time.Since(start)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.results[check.Name]
+	result.Critical = check.Critical
+	result.CheckedAt = &start
+	result.LatencyMS = float64(latency.Microseconds()) / 1000
+
+	if err != nil {
+		result.Status = StatusDown
+		result.LastError = err.Error()
+	} else {
+		result.Status = StatusOK
+		result.LastError = ""
+		result.LastSuccess = &start
+	}
+
+	c.results[check.Name] = result
+}
+
+// HTTPProbe проверяет доступность адреса: любой HTTP-ответ, кроме 5xx, считается успехом
+func HTTPProbe(client *http.Client, url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
@@ -2,8 +2,11 @@ package ManyChat
 
 import (
 	"fmt"
+	"strings"
+	"sync/atomic"
 
 	"tp-go-service/modules"
+	"tp-go-service/modules/TravelPayouts"
 	"tp-go-service/modules/WeGoTrip"
 )
 
@@ -12,15 +15,35 @@ const (
 )
 
 const (
-	FieldAffiliateLink = "Ответ API URLs: афф.ссылка"
-	FieldStatus        = "Ответ API URLs: status"
-	FieldErrorMessage  = "Ответ API URLs: error_message"
-	FieldErrorCode     = "Ответ API URLs: error_code"
+	FieldAffiliateLink     = "Ответ API URLs: афф.ссылка"
+	FieldAffiliateLinkFull = "Ответ API URLs: афф.ссылка (полная)"
+	FieldStatus            = "Ответ API URLs: status"
+	FieldErrorMessage      = "Ответ API URLs: error_message"
+	FieldErrorCode         = "Ответ API URLs: error_code"
+	FieldFallback          = "Ответ API URLs: fallback"
+	FieldRequestID         = "Ответ API URLs: request_id"
+
+	FieldBatchAffiliateLink     = "Ответ API URLs: афф.ссылка [%d]"
+	FieldBatchAffiliateLinkFull = "Ответ API URLs: афф.ссылка (полная) [%d]"
+	FieldBatchStatus            = "Ответ API URLs: status [%d]"
+	FieldBatchErrorMessage      = "Ответ API URLs: error_message [%d]"
+	FieldBatchErrorCode         = "Ответ API URLs: error_code [%d]"
 
 	FieldTopPrice = "Ответ TOP-подборок [%d]: Price"
 	FieldTopURL   = "Ответ TOP-подборок [%d]: URL"
 	FieldTopImage = "Ответ TOP-подборок [%d]: Картинка"
 	FieldTopTitle = "Ответ TOP-подборок [%d]: Название"
+
+	FieldFeedCity       = "Ответ TOP-подборок: город"
+	FieldFeedCityMatch  = "Ответ TOP-подборок: city_match"
+	FieldFeedPage       = "Ответ TOP-подборок: page"
+	FieldFeedTotalItems = "Ответ TOP-подборок: total_items"
+	FieldFeedTotalPages = "Ответ TOP-подборок: total_pages"
+	FieldFeedHasNext    = "Ответ TOP-подборок: has_next"
+	FieldFeedNextPage   = "Ответ TOP-подборок: next_page"
+
+	FieldCitySuggestion  = "Ответ TOP-подборок: вариант города [%d]"
+	FieldCitySuggestions = "Ответ TOP-подборок: вариантов города"
 )
 
 type ManyChat struct {
@@ -45,11 +68,49 @@ type Action struct {
 	Value     any    `json:"value"`
 }
 
-func New() *ManyChat {
-	return &ManyChat{
-		version: "v2",
-		content: "instagram",
+// ErrorCodes возвращает коды ошибок ответа, включая ошибки отдельных ссылок пакета
+func (r Response) ErrorCodes() []string {
+	var codes []string
+	for _, action := range r.Content.Actions {
+		if action.FieldName != FieldErrorCode && !strings.HasPrefix(action.FieldName, FieldErrorCode+" [") {
+			continue
+		}
+		if code, ok := action.Value.(string); ok && code != "" {
+			codes = append(codes, code)
+		}
 	}
+	return codes
+}
+
+// WithRequestID добавляет в ответ идентификатор запроса для поиска в логах
+func (r Response) WithRequestID(id string) Response {
+	r.Content.Actions = append(r.Content.Actions, Action{
+		Action:    ActionSetFieldValue,
+		FieldName: FieldRequestID,
+		Value:     id,
+	})
+	return r
+}
+
+// defaults - формат ответов, который получает New
+var defaults atomic.Pointer[ManyChat]
+
+func init() {
+	SetDefaults("v2", "instagram")
+}
+
+// SetDefaults задает версию и тип содержимого ответов; безопасно вызывать
+// во время работы сервиса
+func SetDefaults(version, content string) {
+	defaults.Store(&ManyChat{
+		version: version,
+		content: content,
+	})
+}
+
+func New() *ManyChat {
+	mc := *defaults.Load()
+	return &mc
 }
 
 // func NewWithParams(version, content string) *ManyChat {
@@ -59,28 +120,171 @@ func New() *ManyChat {
 // 	}
 // }
 
-func (mc *ManyChat) FromTravelPayoutsResponse(link string) Response {
+func (mc *ManyChat) FromTravelPayoutsResponse(link TravelPayouts.LinkResult) Response {
+	actions := []Action{
+		{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldAffiliateLink,
+			Value:     link.PartnerURL,
+		},
+	}
+
+	if link.FullURL != "" {
+		actions = append(actions, Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldAffiliateLinkFull,
+			Value:     link.FullURL,
+		})
+	}
+
+	if link.Fallback {
+		actions = append(actions, Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFallback,
+			Value:     true,
+		})
+	}
+
+	actions = append(actions, Action{
+		Action:    ActionSetFieldValue,
+		FieldName: FieldStatus,
+		Value:     true,
+	})
+
 	return Response{
 		Version: mc.version,
 		Content: Content{
 			Type:     mc.content,
 			Messages: []string{},
-			Actions: []Action{
-				{
+			Actions:  actions,
+		},
+	}
+}
+
+func (mc *ManyChat) FromTravelPayoutsBatchResponse(results []TravelPayouts.LinkResult) Response {
+	var actions []Action
+	fallback := false
+
+	for i, result := range results {
+		index := i + 1
+		fallback = fallback || result.Fallback
+
+		if result.Error != nil {
+			actions = append(actions,
+				Action{
 					Action:    ActionSetFieldValue,
-					FieldName: FieldAffiliateLink,
-					Value:     link,
+					FieldName: fmt.Sprintf(FieldBatchErrorMessage, index),
+					Value:     result.Error.GetMessage(),
 				},
-				{
+				Action{
 					Action:    ActionSetFieldValue,
-					FieldName: FieldStatus,
-					Value:     true,
+					FieldName: fmt.Sprintf(FieldBatchErrorCode, index),
+					Value:     result.Error.GetCode(),
 				},
-			},
+				Action{
+					Action:    ActionSetFieldValue,
+					FieldName: fmt.Sprintf(FieldBatchStatus, index),
+					Value:     false,
+				},
+			)
+			continue
+		}
+
+		actions = append(actions, Action{
+			Action:    ActionSetFieldValue,
+			FieldName: fmt.Sprintf(FieldBatchAffiliateLink, index),
+			Value:     result.PartnerURL,
+		})
+
+		if result.FullURL != "" {
+			actions = append(actions, Action{
+				Action:    ActionSetFieldValue,
+				FieldName: fmt.Sprintf(FieldBatchAffiliateLinkFull, index),
+				Value:     result.FullURL,
+			})
+		}
+
+		actions = append(actions, Action{
+			Action:    ActionSetFieldValue,
+			FieldName: fmt.Sprintf(FieldBatchStatus, index),
+			Value:     true,
+		})
+	}
+
+	if fallback {
+		actions = append(actions, Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFallback,
+			Value:     true,
+		})
+	}
+
+	actions = append(actions,
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldStatus,
+			Value:     true,
+		},
+	)
+
+	return Response{
+		Version: mc.version,
+		Content: Content{
+			Type:     mc.content,
+			Messages: []string{},
+			Actions:  actions,
 		},
 	}
 }
 
+// FromWeGoTripFeed формирует ответ со страницей подборки, найденным городом
+// (название из каталога и способ поиска, чтобы бот мог переспросить при
+// city_match = fuzzy) и данными для кнопки "показать еще": номер страницы,
+// всего экскурсий и страниц, есть ли следующая
+func (mc *ManyChat) FromWeGoTripFeed(feed WeGoTrip.Feed) Response {
+	response := mc.FromWeGoGetRespose(feed.Items)
+
+	response.Content.Actions = append(response.Content.Actions,
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedCity,
+			Value:     feed.City.Name,
+		},
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedCityMatch,
+			Value:     feed.City.Method,
+		},
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedPage,
+			Value:     feed.Page,
+		},
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedTotalItems,
+			Value:     feed.TotalItems,
+		},
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedTotalPages,
+			Value:     feed.TotalPages,
+		},
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedHasNext,
+			Value:     feed.HasNext,
+		},
+		Action{
+			Action:    ActionSetFieldValue,
+			FieldName: FieldFeedNextPage,
+			Value:     feed.NextPage,
+		},
+	)
+
+	return response
+}
+
 func (mc *ManyChat) FromWeGoGetRespose(feedItems []WeGoTrip.FeedItem) Response {
 	var actions []Action
 
@@ -130,7 +334,7 @@ func (mc *ManyChat) FromWeGoGetRespose(feedItems []WeGoTrip.FeedItem) Response {
 }
 
 func (mc *ManyChat) FromError(err modules.APIError) Response {
-	return Response{
+	response := Response{
 		Version: mc.version,
 		Content: Content{
 			Type:     mc.content,
@@ -154,6 +358,31 @@ func (mc *ManyChat) FromError(err modules.APIError) Response {
 			},
 		},
 	}
+
+	if cityErr, ok := err.(*WeGoTrip.CityNotFoundError); ok {
+		response.Content.Actions = append(response.Content.Actions, citySuggestionActions(cityErr.Suggestions)...)
+	}
+
+	return response
+}
+
+// citySuggestionActions - похожие города для быстрых ответов: поля
+// "вариант города [N]" с 1 и их количество
+func citySuggestionActions(suggestions []string) []Action {
+	actions := make([]Action, 0, len(suggestions)+1)
+	for i, suggestion := range suggestions {
+		actions = append(actions, Action{
+			Action:    ActionSetFieldValue,
+			FieldName: fmt.Sprintf(FieldCitySuggestion, i+1),
+			Value:     suggestion,
+		})
+	}
+
+	return append(actions, Action{
+		Action:    ActionSetFieldValue,
+		FieldName: FieldCitySuggestions,
+		Value:     len(suggestions),
+	})
 }
 
 func (mc *ManyChat) FromValidationError(message string) Response {
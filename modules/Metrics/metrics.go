@@ -0,0 +1,117 @@
+package Metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"tp-go-service/modules"
+)
+
+const namespace = "tp"
+
+var (
+	// HTTPRequests - входящие запросы по маршруту, методу и HTTP-статусу
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Входящие HTTP-запросы.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPDuration - время обработки входящих запросов
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки входящих HTTP-запросов.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"route", "method"})
+
+	// UpstreamDuration - время каждой попытки запроса к внешнему API;
+	// outcome - HTTP-статус ответа или код сетевой ошибки
+	UpstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Время запросов к внешним API.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2, 4, 8, 16},
+	}, []string{"provider", "outcome"})
+
+	// APIErrors - коды ошибок, отданные клиентам в ответах ManyChat
+	APIErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_errors_total",
+		Help:      "Коды ошибок в ответах API.",
+	}, []string{"route", "code"})
+
+	// CacheLookups - обращения к кэшу по результату (hit/miss)
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Обращения к кэшу.",
+	}, []string{"cache", "result"})
+
+	// ConfigReloads - перезагрузки конфигурации по результату (success/error)
+	ConfigReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_reloads_total",
+		Help:      "Перезагрузки конфигурации и файлов данных.",
+	}, []string{"result"})
+
+	// ConfigReloadTime - время последней успешной загрузки конфигурации (unix)
+	ConfigReloadTime = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_last_reload_success_timestamp_seconds",
+		Help:      "Время последней успешной загрузки конфигурации.",
+	})
+)
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveCache учитывает hits попаданий и misses промахов кэша name
+func ObserveCache(name string, hits, misses int) {
+	CacheLookups.WithLabelValues(name, "hit").Add(float64(hits))
+	CacheLookups.WithLabelValues(name, "miss").Add(float64(misses))
+}
+
+// RegisterBreaker публикует состояние предохранителя: 0 - closed, 1 - open, 2 - half-open
+func RegisterBreaker(breaker *modules.CircuitBreaker) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "upstream_breaker_state",
+		Help:        "Состояние предохранителя внешнего API: 0 - closed, 1 - open, 2 - half-open.",
+		ConstLabels: prometheus.Labels{"provider": breaker.Name()},
+	}, func() float64 {
+		return float64(breaker.State())
+	})
+}
+
+// Transport измеряет время запросов к внешнему API provider
+type Transport struct {
+	Base     http.RoundTripper
+	Provider string
+}
+
+func NewTransport(base http.RoundTripper, provider string) *Transport {
+	return &Transport{Base: base, Provider: provider}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+
+	outcome := ""
+	if err != nil {
+		outcome = modules.UpstreamErrorCode(err)
+	} else {
+		outcome = strconv.Itoa(resp.StatusCode)
+	}
+
+	UpstreamDuration.WithLabelValues(t.Provider, outcome).Observe(time.Since(start).Seconds())
+	return resp, err
+}
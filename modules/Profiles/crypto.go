@@ -0,0 +1,57 @@
+package Profiles
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+// Cipher шифрует токены профилей для хранения в базе (AES-256-GCM,
+// ключ получается из секрета через SHA-256)
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+func NewCipher(secret string) (*Cipher, error) {
+	if secret == "" {
+		return nil, errors.New("не задан секрет шифрования профилей")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt возвращает nonce, за которым следует зашифрованный текст
+func (c *Cipher) Encrypt(plaintext string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, []byte(plaintext), nil), nil
+}
+
+func (c *Cipher) Decrypt(ciphertext []byte) (string, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return "", errors.New("поврежденные данные профиля")
+	}
+
+	plaintext, err := c.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
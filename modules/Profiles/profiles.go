@@ -0,0 +1,193 @@
+package Profiles
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"tp-go-service/modules"
+)
+
+// Profile - именованный набор учетных данных Travelpayouts
+type Profile struct {
+	Name   string `json:"name"`
+	Token  string `json:"token"`
+	TRS    string `json:"trs"`
+	Marker string `json:"marker"`
+}
+
+// Overrides - учетные данные из тела запроса, заменяющие поля профиля
+type Overrides struct {
+	Token  string
+	TRS    string
+	Marker string
+}
+
+type ProfilesError struct {
+	modules.BaseError
+}
+
+func NewProfilesError(code, message string) modules.APIError {
+	return &ProfilesError{
+		BaseError: modules.BaseError{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+type Registry struct {
+	mu             sync.RWMutex
+	profiles       map[string]Profile
+	defaultProfile string
+}
+
+func New(defaultProfile string) *Registry {
+	return &Registry{
+		profiles:       make(map[string]Profile),
+		defaultProfile: NormalizeName(defaultProfile),
+	}
+}
+
+// Add добавляет или заменяет профиль
+func (r *Registry) Add(profile Profile) {
+	profile.Name = NormalizeName(profile.Name)
+
+	r.mu.Lock()
+	r.profiles[profile.Name] = profile
+	r.mu.Unlock()
+}
+
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	delete(r.profiles, NormalizeName(name))
+	r.mu.Unlock()
+}
+
+func (r *Registry) Get(name string) (Profile, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	profile, ok := r.profiles[NormalizeName(name)]
+	return profile, ok
+}
+
+// Names возвращает имена профилей без учетных данных
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tokens возвращает токены всех профилей (для скрытия в логах)
+func (r *Registry) Tokens() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tokens := make([]string, 0, len(r.profiles))
+	for _, profile := range r.profiles {
+		if profile.Token != "" {
+			tokens = append(tokens, profile.Token)
+		}
+	}
+	return tokens
+}
+
+// Resolve собирает учетные данные запроса: профиль по имени (или профиль
+// по умолчанию), поверх которого применяются непустые поля из запроса
+func (r *Registry) Resolve(name string, overrides Overrides) (Profile, modules.APIError) {
+	var profile Profile
+
+	if name == "" {
+		name = r.defaultProfile
+	}
+
+	if name != "" {
+		found, ok := r.Get(name)
+		if !ok {
+			return Profile{}, NewProfilesError("profile_not_found", "профиль не найден: "+name)
+		}
+		profile = found
+	}
+
+	return apply(profile, overrides)
+}
+
+// Explicit возвращает учетные данные только из полей запроса, без профилей сервиса
+func Explicit(overrides Overrides) (Profile, modules.APIError) {
+	return apply(Profile{}, overrides)
+}
+
+// apply применяет непустые поля запроса к профилю и проверяет, что учетные данные полные
+func apply(profile Profile, overrides Overrides) (Profile, modules.APIError) {
+	if overrides.Token != "" {
+		profile.Token = overrides.Token
+	}
+	if overrides.TRS != "" {
+		profile.TRS = overrides.TRS
+	}
+	if overrides.Marker != "" {
+		profile.Marker = overrides.Marker
+	}
+
+	if profile.Token == "" || profile.TRS == "" || profile.Marker == "" {
+		return Profile{}, NewProfilesError("missing_credentials", "не заданы token, trs и marker: передайте их в запросе или выберите профиль")
+	}
+
+	return profile, nil
+}
+
+// FromEnv читает профили из переменных TP_PROFILE_<ИМЯ>_TOKEN, _TRS и _MARKER
+func FromEnv(environ []string) []Profile {
+	byName := make(map[string]*Profile)
+
+	for _, pair := range environ {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || !strings.HasPrefix(key, "TP_PROFILE_") {
+			continue
+		}
+
+		rest := strings.TrimPrefix(key, "TP_PROFILE_")
+		separator := strings.LastIndex(rest, "_")
+		if separator <= 0 {
+			continue
+		}
+
+		name := NormalizeName(rest[:separator])
+		profile, ok := byName[name]
+		if !ok {
+			profile = &Profile{Name: name}
+			byName[name] = profile
+		}
+
+		switch rest[separator+1:] {
+		case "TOKEN":
+			profile.Token = value
+		case "TRS":
+			profile.TRS = value
+		case "MARKER":
+			profile.Marker = value
+		}
+	}
+
+	profiles := make([]Profile, 0, len(byName))
+	for _, profile := range byName {
+		profiles = append(profiles, *profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	return profiles
+}
+
+// NormalizeName приводит имя профиля к виду, в котором профили хранятся и ищутся
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
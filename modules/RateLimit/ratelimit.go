@@ -0,0 +1,164 @@
+package RateLimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit - ограничение в виде корзины токенов: Burst запросов сразу,
+// затем Rate запросов в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited сообщает, что ограничение выключено
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// ParseLimit разбирает ограничение вида "60/m", "10/s,burst=20" или "0" (без ограничения).
+// По умолчанию burst равен количеству запросов за период.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+
+	rateSpec, burstSpec, hasBurst := strings.Cut(value, ",")
+
+	countSpec, unit, ok := strings.Cut(rateSpec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("неверное ограничение %q: ожидается формат 60/m", value)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(countSpec))
+	if err != nil || count < 0 {
+		return Limit{}, fmt.Errorf("неверное количество запросов в %q", value)
+	}
+
+	var period time.Duration
+	switch strings.TrimSpace(unit) {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("неверный период в %q: используйте s, m или h", value)
+	}
+
+	limit := Limit{
+		Rate:  float64(count) / period.Seconds(),
+		Burst: count,
+	}
+
+	if hasBurst {
+		burst, ok := strings.CutPrefix(strings.TrimSpace(burstSpec), "burst=")
+		if !ok {
+			return Limit{}, fmt.Errorf("неверный burst в %q", value)
+		}
+		limit.Burst, err = strconv.Atoi(burst)
+		if err != nil || limit.Burst < 0 {
+			return Limit{}, fmt.Errorf("неверный burst в %q", value)
+		}
+	}
+
+	return limit, nil
+}
+
+// Result - решение ограничителя для заголовков RateLimit-*
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset - через сколько корзина снова заполнится полностью
+	Reset time.Duration
+	// RetryAfter - через сколько появится следующий токен (для отказа)
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// sweepEvery - как часто (в вызовах) удалять заполненные простаивающие корзины
+const sweepEvery = 1024
+
+// Limiter - набор корзин токенов с одним ограничением, по корзине на ключ
+type Limiter struct {
+	limit Limit
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+func New(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow расходует токен из корзины key, если он есть
+func (l *Limiter) Allow(key string) Result {
+	if l.limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate)
+	b.updated = now
+
+	result := Result{Limit: l.limit.Burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(float64(l.limit.Burst) - b.tokens)
+
+	return result
+}
+
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.limit.Rate >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package Storage
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"tp-go-service/modules/Auth"
+)
+
+// APIKey - API-ключ клиента (хранится только хэш)
+type APIKey struct {
+	ID        uint     `gorm:"primaryKey"`
+	Name      string   `gorm:"not null"`
+	Hint      string   `gorm:"not null"`
+	Hash      string   `gorm:"uniqueIndex;not null"`
+	Scopes    []string `gorm:"serializer:json"`
+	Profile   string
+	CreatedAt time.Time
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+func (row APIKey) toKey() Auth.Key {
+	return Auth.Key{
+		ID:        row.ID,
+		Name:      row.Name,
+		Hint:      row.Hint,
+		Hash:      row.Hash,
+		Scopes:    row.Scopes,
+		Profile:   row.Profile,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+		RevokedAt: row.RevokedAt,
+	}
+}
+
+func (s *Storage) CreateAPIKey(key *Auth.Key) error {
+	row := APIKey{
+		Name:      key.Name,
+		Hint:      key.Hint,
+		Hash:      key.Hash,
+		Scopes:    key.Scopes,
+		Profile:   key.Profile,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+	}
+	if err := s.db.Create(&row).Error; err != nil {
+		return err
+	}
+	key.ID = row.ID
+	return nil
+}
+
+func (s *Storage) FindAPIKeyByHash(hash string) (Auth.Key, error) {
+	var row APIKey
+	err := s.db.Where("hash = ?", hash).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Auth.Key{}, Auth.ErrNotFound
+	}
+	if err != nil {
+		return Auth.Key{}, err
+	}
+	return row.toKey(), nil
+}
+
+func (s *Storage) GetAPIKey(id uint) (Auth.Key, error) {
+	var row APIKey
+	err := s.db.Take(&row, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Auth.Key{}, Auth.ErrNotFound
+	}
+	if err != nil {
+		return Auth.Key{}, err
+	}
+	return row.toKey(), nil
+}
+
+func (s *Storage) ListAPIKeys() ([]Auth.Key, error) {
+	var rows []APIKey
+	if err := s.db.Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]Auth.Key, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toKey())
+	}
+	return keys, nil
+}
+
+func (s *Storage) UpdateAPIKeyExpiry(id uint, expiresAt *time.Time, revokedAt *time.Time) error {
+	return s.db.Model(&APIKey{}).Where("id = ?", id).Updates(map[string]any{
+		"expires_at": expiresAt,
+		"revoked_at": revokedAt,
+	}).Error
+}
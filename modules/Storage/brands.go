@@ -0,0 +1,64 @@
+package Storage
+
+import (
+	"time"
+
+	"tp-go-service/modules/Brands"
+)
+
+// Brand - бренд каталога в базе данных
+type Brand struct {
+	ID        uint     `gorm:"primaryKey"`
+	Name      string   `gorm:"uniqueIndex;not null"`
+	Aliases   []string `gorm:"serializer:json"`
+	URL       string   `gorm:"not null"`
+	Enabled   bool     `gorm:"not null;default:true"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SeedBrands заполняет каталог брендов, если он пуст
+func (s *Storage) SeedBrands(brands []Brands.Brand) error {
+	var count int64
+	if err := s.db.Model(&Brand{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	rows := make([]Brand, 0, len(brands))
+	for _, brand := range brands {
+		rows = append(rows, Brand{
+			Name:    brand.Name,
+			Aliases: brand.Aliases,
+			URL:     brand.URL,
+			Enabled: brand.Enabled,
+		})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return s.db.Create(&rows).Error
+}
+
+// LoadBrands возвращает все бренды из базы данных
+func (s *Storage) LoadBrands() ([]Brands.Brand, error) {
+	var rows []Brand
+	if err := s.db.Order("name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	brands := make([]Brands.Brand, 0, len(rows))
+	for _, row := range rows {
+		brands = append(brands, Brands.Brand{
+			Name:    row.Name,
+			Aliases: row.Aliases,
+			URL:     row.URL,
+			Enabled: row.Enabled,
+		})
+	}
+
+	return brands, nil
+}
@@ -0,0 +1,67 @@
+package Storage
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CachedLink - запись постоянного кэша аффилиатных ссылок
+type CachedLink struct {
+	Key         string    `gorm:"primaryKey"`
+	OriginalURL string    `gorm:"not null"`
+	TRS         string    `gorm:"not null"`
+	Marker      string    `gorm:"not null"`
+	SubID       string    `gorm:"not null"`
+	Shorten     bool      `gorm:"not null;default:true"`
+	PartnerURL  string    `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"index;not null"`
+	CreatedAt   time.Time
+}
+
+func (s *Storage) GetCachedLink(key string, now time.Time) (string, time.Time, bool, error) {
+	var row CachedLink
+	err := s.db.Where("key = ? AND expires_at > ?", key, now).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", time.Time{}, false, nil
+	}
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+	return row.PartnerURL, row.ExpiresAt, true, nil
+}
+
+func (s *Storage) PutCachedLink(key, originalURL, trs, marker, subID string, shorten bool, partnerURL string, expiresAt time.Time) error {
+	row := CachedLink{
+		Key:         key,
+		OriginalURL: originalURL,
+		TRS:         trs,
+		Marker:      marker,
+		SubID:       subID,
+		Shorten:     shorten,
+		PartnerURL:  partnerURL,
+		ExpiresAt:   expiresAt,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"partner_url", "expires_at"}),
+	}).Create(&row).Error
+}
+
+// PruneCachedLinks удаляет устаревшие записи и самые старые сверх maxEntries
+func (s *Storage) PruneCachedLinks(now time.Time, maxEntries int) error {
+	if err := s.db.Where("expires_at <= ?", now).Delete(&CachedLink{}).Error; err != nil {
+		return err
+	}
+
+	if maxEntries <= 0 {
+		return nil
+	}
+
+	return s.db.Exec(
+		"DELETE FROM cached_links WHERE key NOT IN (SELECT key FROM cached_links ORDER BY expires_at DESC LIMIT ?)",
+		maxEntries,
+	).Error
+}
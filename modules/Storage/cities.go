@@ -0,0 +1,36 @@
+package Storage
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// CitySlug - slug города WeGoTrip, полученный из ответа API подборок
+type CitySlug struct {
+	ID        uint   `gorm:"primaryKey"`
+	Domain    string `gorm:"uniqueIndex:idx_city_slugs_city;not null"`
+	CityID    int    `gorm:"uniqueIndex:idx_city_slugs_city;not null"`
+	Slug      string `gorm:"not null"`
+	UpdatedAt time.Time
+}
+
+// SaveCitySlug сохраняет или обновляет slug города
+func (s *Storage) SaveCitySlug(domain string, cityID int, slug string) error {
+	row := CitySlug{
+		Domain: domain,
+		CityID: cityID,
+		Slug:   slug,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "domain"}, {Name: "city_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"slug", "updated_at"}),
+	}).Create(&row).Error
+}
+
+// LoadCitySlugs возвращает все известные slug городов
+func (s *Storage) LoadCitySlugs() ([]CitySlug, error) {
+	var rows []CitySlug
+	err := s.db.Order("domain, city_id").Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,113 @@
+package Storage
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration - примененная миграция схемы
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+type migration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+// migrations применяются по возрастанию версии, уже примененные пропускаются.
+// Новые миграции добавляются только в конец списка.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "create_brands_links_feed_lookups",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&Brand{}, &Link{}, &FeedLookup{})
+		},
+	},
+	{
+		version: 2,
+		name:    "create_cached_links",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&CachedLink{})
+		},
+	},
+	{
+		version: 3,
+		name:    "add_shorten_to_links",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&Link{}, &CachedLink{})
+		},
+	},
+	{
+		version: 4,
+		name:    "create_credential_profiles",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&CredentialProfile{})
+		},
+	},
+	{
+		version: 5,
+		name:    "create_api_keys",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&APIKey{})
+		},
+	},
+	{
+		version: 6,
+		name:    "add_page_size_to_feed_lookups",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&FeedLookup{})
+		},
+	},
+	{
+		version: 7,
+		name:    "create_city_slugs",
+		up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&CitySlug{})
+		},
+	},
+}
+
+func (s *Storage) migrate() error {
+	if err := s.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("создание таблицы миграций: %w", err)
+	}
+
+	var applied []SchemaMigration
+	if err := s.db.Find(&applied).Error; err != nil {
+		return fmt.Errorf("чтение примененных миграций: %w", err)
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	for _, m := range migrations {
+		if done[m.version] {
+			continue
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   m.version,
+				Name:      m.name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("миграция %d (%s): %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package Storage
+
+import (
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// CredentialProfile - профиль учетных данных Travelpayouts с зашифрованным токеном
+type CredentialProfile struct {
+	ID             uint   `gorm:"primaryKey"`
+	Name           string `gorm:"uniqueIndex;not null"`
+	TokenEncrypted []byte `gorm:"not null"`
+	TRS            string `gorm:"not null"`
+	Marker         string `gorm:"not null"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (s *Storage) SaveCredentialProfile(profile *CredentialProfile) error {
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token_encrypted", "trs", "marker", "updated_at"}),
+	}).Create(profile).Error
+}
+
+func (s *Storage) DeleteCredentialProfile(name string) error {
+	return s.db.Where("name = ?", name).Delete(&CredentialProfile{}).Error
+}
+
+func (s *Storage) LoadCredentialProfiles() ([]CredentialProfile, error) {
+	var profiles []CredentialProfile
+	err := s.db.Order("name").Find(&profiles).Error
+	return profiles, err
+}
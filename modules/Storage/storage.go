@@ -0,0 +1,128 @@
+package Storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// DefaultPath - путь к базе внутри смонтированной директории data
+const DefaultPath = "data/tp-go-service.db"
+
+type Storage struct {
+	db *gorm.DB
+}
+
+// Link - созданная аффилиатная ссылка
+type Link struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	OriginalURL string    `gorm:"index;not null" json:"original_url"`
+	PartnerURL  string    `gorm:"not null" json:"partner_url"`
+	TRS         string    `json:"trs"`
+	Marker      string    `json:"marker"`
+	SubID       string    `json:"sub_id"`
+	Shorten     bool      `gorm:"not null;default:true" json:"shorten"`
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
+
+// FeedLookup - запрос подборки WeGoTrip
+type FeedLookup struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	City      string    `gorm:"index;not null" json:"city"`
+	Lang      string    `json:"lang"`
+	Currency  string    `json:"currency"`
+	Page      int       `json:"page"`
+	PageSize  int       `json:"page_size"`
+	Items     int       `json:"items"`
+	ErrorCode string    `json:"error_code"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// Open открывает (или создает) базу SQLite и применяет миграции
+func Open(path string) (*Storage, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("создание директории базы данных: %w", err)
+		}
+	}
+
+	dsn := path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("открытие базы данных: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("получение соединения с базой данных: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	storage := &Storage{db: db}
+	if err := storage.migrate(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Ping проверяет соединение с базой данных
+func (s *Storage) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close переносит журнал WAL в файл базы и закрывает соединение
+func (s *Storage) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)").Error; err != nil {
+		sqlDB.Close()
+		return fmt.Errorf("сброс журнала базы данных: %w", err)
+	}
+
+	return sqlDB.Close()
+}
+
+// RecordLink сохраняет созданную аффилиатную ссылку
+func (s *Storage) RecordLink(link *Link) error {
+	return s.db.Create(link).Error
+}
+
+// RecordFeedLookup сохраняет запрос подборки WeGoTrip
+func (s *Storage) RecordFeedLookup(lookup *FeedLookup) error {
+	return s.db.Create(lookup).Error
+}
+
+// RecentLinks возвращает последние созданные ссылки
+func (s *Storage) RecentLinks(limit int) ([]Link, error) {
+	var links []Link
+	err := s.db.Order("created_at DESC, id DESC").Limit(limit).Find(&links).Error
+	return links, err
+}
+
+// RecentFeedLookups возвращает последние запросы подборок
+func (s *Storage) RecentFeedLookups(limit int) ([]FeedLookup, error) {
+	var lookups []FeedLookup
+	err := s.db.Order("created_at DESC, id DESC").Limit(limit).Find(&lookups).Error
+	return lookups, err
+}
@@ -0,0 +1,139 @@
+package Tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"tp-go-service/modules"
+)
+
+// instrumentationName - имя трассировщика сервиса
+const instrumentationName = "tp-go-service"
+
+// ErrorCodeKey - атрибут с кодом ошибки API сервиса
+const ErrorCodeKey = attribute.Key("tp.error_code")
+
+// Config - параметры экспорта трассировок по OTLP/HTTP
+type Config struct {
+	Enabled bool
+	// Endpoint - адрес коллектора, например http://localhost:4318; пустое
+	// значение - стандартные переменные OTEL_EXPORTER_OTLP_*
+	Endpoint    string
+	ServiceName string
+	SampleRatio float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Enabled:     false,
+		ServiceName: "tp-go-service",
+		SampleRatio: 1,
+	}
+}
+
+// Setup настраивает глобальный провайдер трассировок. Возвращает функцию,
+// которая отправляет накопленные спаны и останавливает экспорт.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracehttp.Option
+	if config.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpointURL(config.Endpoint))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(config.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трассировщик сервиса
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// End отмечает в спане код ошибки API и завершает его
+func End(span trace.Span, err modules.APIError) {
+	if err != nil {
+		SetError(span, err.GetCode(), err.GetMessage())
+	}
+	span.End()
+}
+
+// SetError отмечает спан как завершившийся ошибкой с кодом code
+func SetError(span trace.Span, code, message string) {
+	span.SetAttributes(ErrorCodeKey.String(code))
+	span.SetStatus(codes.Error, message)
+}
+
+// Transport создает клиентский спан на каждую попытку запроса к внешнему API
+// и передает контекст трассировки в заголовке traceparent
+type Transport struct {
+	Base     http.RoundTripper
+	Provider string
+}
+
+func NewTransport(base http.RoundTripper, provider string) *Transport {
+	return &Transport{Base: base, Provider: provider}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(req.Context(), req.Method+" "+t.Provider,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("tp.provider", t.Provider),
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		SetError(span, modules.UpstreamErrorCode(err), err.Error())
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, strings.TrimSpace(resp.Status))
+	}
+
+	return resp, nil
+}
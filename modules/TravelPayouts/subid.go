@@ -0,0 +1,152 @@
+package TravelPayouts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"tp-go-service/modules"
+)
+
+// MaxSubIDLength - максимальная длина sub_id, которую принимает Travelpayouts
+const MaxSubIDLength = 64
+
+var (
+	subIDPattern       = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	subIDPlaceholder   = regexp.MustCompile(`\{([a-z_]+)\}`)
+	subIDDisallowedRun = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// SubIDVars - значения для подстановки в шаблон sub_id
+type SubIDVars struct {
+	Channel  string
+	Flow     string
+	Campaign string
+	Date     time.Time
+}
+
+func (v SubIDVars) lookup(name string) (string, bool) {
+	switch name {
+	case "channel":
+		return v.Channel, true
+	case "flow":
+		return v.Flow, true
+	case "campaign":
+		return v.Campaign, true
+	case "date":
+		return v.Date.UTC().Format("20060102"), true
+	}
+	return "", false
+}
+
+// SubIDTemplates - шаблоны sub_id, заданные на стороне сервиса.
+// Шаблон состоит из текста и подстановок {channel}, {flow}, {campaign}, {date}.
+type SubIDTemplates struct {
+	defaultTemplate string
+	named           map[string]string
+}
+
+func NewSubIDTemplates(defaultTemplate string, named map[string]string) (*SubIDTemplates, error) {
+	if defaultTemplate == "" {
+		defaultTemplate = DefaultSubID
+	}
+
+	if err := validateTemplate(defaultTemplate); err != nil {
+		return nil, fmt.Errorf("шаблон sub_id по умолчанию: %w", err)
+	}
+
+	templates := make(map[string]string, len(named))
+	for name, template := range named {
+		if err := validateTemplate(template); err != nil {
+			return nil, fmt.Errorf("шаблон sub_id %q: %w", name, err)
+		}
+		templates[name] = template
+	}
+
+	return &SubIDTemplates{
+		defaultTemplate: defaultTemplate,
+		named:           templates,
+	}, nil
+}
+
+// Resolve возвращает sub_id для запроса: явно переданный sub_id, иначе
+// именованный шаблон, иначе шаблон по умолчанию
+func (t *SubIDTemplates) Resolve(subID, templateName string, vars SubIDVars) (string, modules.APIError) {
+	if subID != "" {
+		if err := ValidateSubID(subID); err != nil {
+			return "", err
+		}
+		return subID, nil
+	}
+
+	template := t.defaultTemplate
+	if templateName != "" {
+		named, ok := t.named[templateName]
+		if !ok {
+			return "", NewTravelPayoutsError("invalid_sub_id", "неизвестный шаблон sub_id: "+templateName)
+		}
+		template = named
+	}
+
+	if vars.Date.IsZero() {
+		vars.Date = time.Now()
+	}
+
+	rendered := renderTemplate(template, vars)
+	if err := ValidateSubID(rendered); err != nil {
+		return "", err
+	}
+
+	return rendered, nil
+}
+
+// ValidateSubID проверяет sub_id по правилам Travelpayouts
+func ValidateSubID(subID string) modules.APIError {
+	if subID == "" {
+		return NewTravelPayoutsError("invalid_sub_id", "sub_id не может быть пустым")
+	}
+	if len(subID) > MaxSubIDLength {
+		return NewTravelPayoutsError("invalid_sub_id", fmt.Sprintf("sub_id длиннее %d символов", MaxSubIDLength))
+	}
+	if !subIDPattern.MatchString(subID) {
+		return NewTravelPayoutsError("invalid_sub_id", "sub_id может содержать только латинские буквы, цифры, _ и -")
+	}
+	return nil
+}
+
+// renderTemplate подставляет значения в шаблон. Недопустимые символы
+// в значениях заменяются на _, пустые значения пропускаются вместе
+// с лишними разделителями.
+func renderTemplate(template string, vars SubIDVars) string {
+	rendered := subIDPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, _ := vars.lookup(placeholder[1 : len(placeholder)-1])
+		return strings.Trim(subIDDisallowedRun.ReplaceAllString(value, "_"), "_")
+	})
+
+	for strings.Contains(rendered, "__") {
+		rendered = strings.ReplaceAll(rendered, "__", "_")
+	}
+
+	return strings.Trim(rendered, "_-")
+}
+
+func validateTemplate(template string) error {
+	for _, match := range subIDPlaceholder.FindAllStringSubmatch(template, -1) {
+		if _, ok := (SubIDVars{}).lookup(match[1]); !ok {
+			return fmt.Errorf("неизвестная подстановка {%s}", match[1])
+		}
+	}
+
+	sample := renderTemplate(template, SubIDVars{
+		Channel:  "channel",
+		Flow:     "flow",
+		Campaign: "campaign",
+		Date:     time.Now(),
+	})
+	if err := ValidateSubID(sample); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,20 +2,42 @@ package TravelPayouts
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
-	"time"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"tp-go-service/modules"
+	"tp-go-service/modules/Tracing"
 )
 
+// DefaultSubID - sub_id, с которым создаются ссылки по умолчанию
+const DefaultSubID = "social_tool_main"
+
+// MaxLinksPerRequest - максимум ссылок в одном запросе к links/v1/create
+const MaxLinksPerRequest = 10
+
+// TravelPayouts - клиент Travelpayouts API. Один клиент используется всеми
+// запросами сервиса, учетные данные передаются в каждый вызов.
 type TravelPayouts struct {
-	token  string
-	trs    int
-	marker int
-	client *http.Client
+	client  *http.Client
+	baseURL string
+}
+
+// DefaultBaseURL - адрес Travelpayouts API
+const DefaultBaseURL = "https://api.travelpayouts.com"
+
+// Credentials - учетные данные партнера Travelpayouts
+type Credentials struct {
+	Token  string
+	TRS    int
+	Marker int
 }
 
 type TravelPayoutsError struct {
@@ -63,6 +85,18 @@ type TravelPayoutsResponseLink struct {
 	PartnerURL string `json:"partner_url"`
 }
 
+// LinkResult - результат конвертации одной ссылки. PartnerURL - ссылка
+// в запрошенном виде, ShortURL и FullURL заполнены, если API их вернул.
+// Fallback означает, что API недоступен и вместо партнерской возвращена исходная ссылка.
+type LinkResult struct {
+	URL        string
+	PartnerURL string
+	ShortURL   string
+	FullURL    string
+	Fallback   bool
+	Error      modules.APIError
+}
+
 type TravelPayoutsErrorResponse struct {
 	Error   string `json:"error"`
 	Code    string `json:"code"`
@@ -70,101 +104,241 @@ type TravelPayoutsErrorResponse struct {
 	Message string `json:"message"`
 }
 
-func New(token, trs, marker string) (*TravelPayouts, modules.APIError) {
+// New создает клиент Travelpayouts API; при client == nil используется
+// клиент с повторными запросами по умолчанию, при пустом baseURL - DefaultBaseURL
+func New(client *http.Client, baseURL string) *TravelPayouts {
+	if client == nil {
+		client = modules.NewHTTPClient(http.DefaultTransport, modules.DefaultRetryConfig())
+	}
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &TravelPayouts{
+		client:  client,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// NewCredentials проверяет и разбирает учетные данные из запроса
+func NewCredentials(token, trs, marker string) (Credentials, modules.APIError) {
 	trsInt, err := strconv.Atoi(trs)
 	if err != nil {
-		return nil, NewTravelPayoutsError("invalid_trs", "неверный формат TRS")
+		return Credentials{}, NewTravelPayoutsError("invalid_trs", "неверный формат TRS")
 	}
 
 	markerInt, err := strconv.Atoi(marker)
 	if err != nil {
-		return nil, NewTravelPayoutsError("invalid_marker", "неверный формат Marker")
+		return Credentials{}, NewTravelPayoutsError("invalid_marker", "неверный формат Marker")
 	}
 
-	return &TravelPayouts{
-		token:  token,
-		trs:    trsInt,
-		marker: markerInt,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	return Credentials{
+		Token:  token,
+		TRS:    trsInt,
+		Marker: markerInt,
 	}, nil
 }
 
+// LinkOptions - параметры создания партнерских ссылок
+type LinkOptions struct {
+	SubID   string
+	Shorten bool
+}
+
+// DefaultLinkOptions - параметры, с которыми ссылки создавались исторически
+func DefaultLinkOptions() LinkOptions {
+	return LinkOptions{
+		SubID:   DefaultSubID,
+		Shorten: true,
+	}
+}
+
 // GetFromLink создает аффилиатную ссылку из обычной ссылки
-func (tp *TravelPayouts) GetFromLink(originalLink string) (string, modules.APIError) {
+func (tp *TravelPayouts) GetFromLink(credentials Credentials, originalLink string, options LinkOptions) (LinkResult, modules.APIError) {
+	return tp.GetFromLinkContext(context.Background(), credentials, originalLink, options)
+}
+
+// GetFromLinkContext создает аффилиатную ссылку, прерывая запрос при отмене ctx
+func (tp *TravelPayouts) GetFromLinkContext(ctx context.Context, credentials Credentials, originalLink string, options LinkOptions) (LinkResult, modules.APIError) {
+	results, err := tp.GetFromLinksContext(ctx, credentials, []string{originalLink}, options)
+	if err != nil {
+		return LinkResult{}, err
+	}
+
+	if results[0].Error != nil {
+		return LinkResult{}, results[0].Error
+	}
+
+	return results[0], nil
+}
+
+// GetFromLinks создает аффилиатные ссылки для нескольких ссылок одним запросом.
+// Результаты возвращаются в порядке исходных ссылок, ошибка отдельной ссылки
+// не прерывает обработку остальных.
+func (tp *TravelPayouts) GetFromLinks(credentials Credentials, originalLinks []string, options LinkOptions) ([]LinkResult, modules.APIError) {
+	return tp.GetFromLinksContext(context.Background(), credentials, originalLinks, options)
+}
+
+// GetFromLinksContext создает аффилиатные ссылки, прерывая запрос при отмене ctx
+func (tp *TravelPayouts) GetFromLinksContext(ctx context.Context, credentials Credentials, originalLinks []string, options LinkOptions) ([]LinkResult, modules.APIError) {
+	ctx, span := Tracing.Tracer().Start(ctx, "TravelPayouts.GetFromLinks", trace.WithAttributes(
+		attribute.Int("tp.trs", credentials.TRS),
+		attribute.Int("tp.links", len(originalLinks)),
+		attribute.StringSlice("tp.link_hosts", linkHosts(originalLinks)),
+		attribute.Bool("tp.shorten", options.Shorten),
+	))
+
+	results, err := tp.getFromLinks(ctx, credentials, originalLinks, options)
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != nil {
+			failed++
+		}
+	}
+	span.SetAttributes(attribute.Int("tp.failed_links", failed))
+
+	Tracing.End(span, err)
+	return results, err
+}
+
+func (tp *TravelPayouts) getFromLinks(ctx context.Context, credentials Credentials, originalLinks []string, options LinkOptions) ([]LinkResult, modules.APIError) {
+	if len(originalLinks) == 0 {
+		return nil, NewTravelPayoutsError("no_links", "не передано ни одной ссылки")
+	}
+	if len(originalLinks) > MaxLinksPerRequest {
+		return nil, NewTravelPayoutsError("too_many_links", fmt.Sprintf("не больше %d ссылок за запрос", MaxLinksPerRequest))
+	}
+
+	subID := options.SubID
+	if subID == "" {
+		subID = DefaultSubID
+	}
+
 	// Создаем запрос к Travelpayouts API
 	request := TravelPayoutsRequest{
-		TRS:     tp.trs,
-		Marker:  tp.marker,
-		Shorten: true,
-		Links: []TravelPayoutsLinkItem{
-			{
-				URL:   originalLink,
-				SubID: "social_tool_main",
-			},
-		},
+		TRS:     credentials.TRS,
+		Marker:  credentials.Marker,
+		Shorten: options.Shorten,
+		Links:   make([]TravelPayoutsLinkItem, 0, len(originalLinks)),
+	}
+
+	for _, link := range originalLinks {
+		request.Links = append(request.Links, TravelPayoutsLinkItem{
+			URL:   link,
+			SubID: subID,
+		})
 	}
 
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", NewTravelPayoutsError("json_error", "ошибка сериализации данных")
+		return nil, NewTravelPayoutsError("json_error", "ошибка сериализации данных")
 	}
 
-	req, err := http.NewRequest("POST", "https://api.travelpayouts.com/links/v1/create", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", tp.baseURL+"/links/v1/create", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", NewTravelPayoutsError("request_error", "ошибка создания запроса")
+		return nil, NewTravelPayoutsError("request_error", "ошибка создания запроса")
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Access-Token", tp.token)
+	req.Header.Set("X-Access-Token", credentials.Token)
 
 	resp, err := tp.client.Do(req)
 	if err != nil {
-		return "", NewTravelPayoutsError("network_error", "ошибка запроса к Travelpayouts API")
+		return nil, requestError(err)
 	}
 	defer resp.Body.Close()
 
 	var responseBody bytes.Buffer
 	_, err = responseBody.ReadFrom(resp.Body)
 	if err != nil {
-		return "", NewTravelPayoutsError("response_error", "ошибка чтения ответа")
+		if code := modules.UpstreamErrorCode(err); code == "deadline_exceeded" || code == "request_canceled" {
+			return nil, requestError(err)
+		}
+		return nil, NewTravelPayoutsError("response_error", "ошибка чтения ответа")
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		var errorResp TravelPayoutsErrorResponse
 		if err := json.Unmarshal(responseBody.Bytes(), &errorResp); err != nil {
-			return "", NewTravelPayoutsError("api_error", fmt.Sprintf("API вернул ошибку %d", resp.StatusCode))
+			return nil, NewTravelPayoutsError("api_error", fmt.Sprintf("API вернул ошибку %d", resp.StatusCode))
 		}
 
 		if errorResp.Error != "" {
-			return "", NewTravelPayoutsError(errorResp.Code, errorResp.Error)
+			return nil, NewTravelPayoutsError(errorResp.Code, errorResp.Error)
 		}
-		return "", NewTravelPayoutsError(errorResp.Code, errorResp.Message)
+		return nil, NewTravelPayoutsError(errorResp.Code, errorResp.Message)
 	}
 
 	var apiResponse TravelPayoutsResponse
 	if err := json.Unmarshal(responseBody.Bytes(), &apiResponse); err != nil {
-		return "", NewTravelPayoutsError("parse_error", "ошибка парсинга ответа")
+		return nil, NewTravelPayoutsError("parse_error", "ошибка парсинга ответа")
 	}
 
 	if apiResponse.Code != "success" {
-		return "", NewTravelPayoutsError(apiResponse.Code, "API вернул код ошибки")
+		return nil, NewTravelPayoutsError(apiResponse.Code, "API вернул код ошибки")
 	}
 
 	if len(apiResponse.Result.Links) == 0 {
-		return "", NewTravelPayoutsError("no_links", "API не вернул ссылок")
+		return nil, NewTravelPayoutsError("no_links", "API не вернул ссылок")
 	}
 
-	link := apiResponse.Result.Links[0]
+	results := make([]LinkResult, len(originalLinks))
+	for i, original := range originalLinks {
+		results[i].URL = original
+
+		if i >= len(apiResponse.Result.Links) {
+			results[i].Error = NewTravelPayoutsError("no_links", "API не вернул ссылку")
+			continue
+		}
+
+		link := apiResponse.Result.Links[i]
+
+		if link.Code != "success" {
+			results[i].Error = NewTravelPayoutsError(link.Code, link.Message)
+			continue
+		}
 
-	if link.Code != "success" {
-		return "", NewTravelPayoutsError(link.Code, link.Message)
+		if link.PartnerURL == "" {
+			results[i].Error = NewTravelPayoutsError("empty_partner_url", "API не вернул партнерскую ссылку")
+			continue
+		}
+
+		results[i].PartnerURL = link.PartnerURL
+		if apiResponse.Result.Shorten {
+			results[i].ShortURL = link.PartnerURL
+		} else {
+			results[i].FullURL = link.PartnerURL
+		}
 	}
 
-	if link.PartnerURL == "" {
-		return "", NewTravelPayoutsError("empty_partner_url", "API не вернул партнерскую ссылку")
+	return results, nil
+}
+
+// linkHosts возвращает хосты ссылок без повторов для атрибутов трассировки
+func linkHosts(links []string) []string {
+	var hosts []string
+	seen := make(map[string]bool, len(links))
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" || seen[u.Host] {
+			continue
+		}
+		seen[u.Host] = true
+		hosts = append(hosts, u.Host)
 	}
+	return hosts
+}
 
-	return link.PartnerURL, nil
+// requestError переводит ошибку HTTP-запроса в ошибку API сервиса
+func requestError(err error) modules.APIError {
+	switch code := modules.UpstreamErrorCode(err); code {
+	case "upstream_unavailable":
+		return NewTravelPayoutsError(code, "Travelpayouts API временно недоступен")
+	case "deadline_exceeded":
+		return NewTravelPayoutsError(code, "превышено время ожидания ответа Travelpayouts API")
+	case "request_canceled":
+		return NewTravelPayoutsError(code, "запрос к Travelpayouts API отменен")
+	default:
+		return NewTravelPayoutsError(code, "ошибка запроса к Travelpayouts API")
+	}
 }
@@ -0,0 +1,41 @@
+package WeGoTrip
+
+// DefaultAliases - сокращения, разговорные и английские названия городов
+// (алиас -> название из таблицы городов). Алиасы сравниваются после
+// нормализации, поэтому регистр, ё и знаки препинания значения не имеют.
+var DefaultAliases = map[string]string{
+	"спб":              "санкт-петербург",
+	"питер":            "санкт-петербург",
+	"петербург":        "санкт-петербург",
+	"ленинград":        "санкт-петербург",
+	"spb":              "санкт-петербург",
+	"piter":            "санкт-петербург",
+	"st petersburg":    "санкт-петербург",
+	"saint petersburg": "санкт-петербург",
+	"petersburg":       "санкт-петербург",
+
+	"мск":    "москва",
+	"msk":    "москва",
+	"moscow": "москва",
+
+	"екб":           "екатеринбург",
+	"ekb":           "екатеринбург",
+	"yekaterinburg": "екатеринбург",
+
+	"new york": "нью-йорк",
+	"nyc":      "нью-йорк",
+
+	"la":          "лос-анджелес",
+	"los angeles": "лос-анджелес",
+
+	"paris":     "париж",
+	"rome":      "рим",
+	"roma":      "рим",
+	"istanbul":  "стамбул",
+	"dubai":     "дубай",
+	"barcelona": "барселона",
+	"prague":    "прага",
+	"praha":     "прага",
+	"vienna":    "вена",
+	"wien":      "вена",
+}
@@ -0,0 +1,148 @@
+package WeGoTrip
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// City - город каталога WeGoTrip. Slug известен, если по городу уже
+// запрашивалась подборка.
+type City struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Slug   string `json:"slug,omitempty"`
+}
+
+// CitySearch - параметры поиска по каталогу городов
+type CitySearch struct {
+	// Query - часть названия; пустая строка - все города
+	Query string
+	// Domain - "com", "ru" или пустая строка для обоих каталогов
+	Domain string
+	// PrefixOnly - искать только по началу названия
+	PrefixOnly bool
+}
+
+// citySlugKey - город, для которого запомнен slug
+type citySlugKey struct {
+	domain string
+	id     int
+}
+
+// citySlugs - slug городов из ответов API подборок
+var citySlugs sync.Map
+
+// SetCitySlug запоминает slug города
+func SetCitySlug(domain string, id int, slug string) {
+	citySlugs.Store(citySlugKey{domain: domain, id: id}, slug)
+}
+
+// CitySlug возвращает запомненный slug города или пустую строку
+func CitySlug(domain string, id int) string {
+	slug, _ := citySlugs.Load(citySlugKey{domain: domain, id: id})
+	s, _ := slug.(string)
+	return s
+}
+
+// SearchCities ищет города действующих таблиц. Название сравнивается после
+// нормализации и в транслитерации, так что "mosk" находит "москва", а алиас
+// "питер" - "санкт-петербург". Сначала идут совпадения по началу названия,
+// затем по подстроке; внутри - по алфавиту, app.wegotrip.com раньше wegotrip.ru.
+func SearchCities(search CitySearch) []City {
+	return resolver.Load().Search(search)
+}
+
+// Search ищет города по таблицам резолвера, см. SearchCities
+func (r *Resolver) Search(search CitySearch) []City {
+	key := NormalizeCityName(search.Query)
+	latin := transliterate(key)
+
+	// rank - 0 для совпадения по началу, 1 для подстроки, -1 - нет совпадения
+	rank := func(name, nameLatin string) int {
+		if key == "" {
+			return 0
+		}
+		if strings.HasPrefix(name, key) || strings.HasPrefix(nameLatin, latin) {
+			return 0
+		}
+		if !search.PrefixOnly && (strings.Contains(name, key) || strings.Contains(nameLatin, latin)) {
+			return 1
+		}
+		return -1
+	}
+
+	// Алиасы находят город, на который указывают
+	aliasRanks := make(map[string]int)
+	if key != "" {
+		for alias, name := range r.cities.Aliases {
+			aliasKey := NormalizeCityName(alias)
+			target := NormalizeCityName(name)
+			if aliasRank := rank(aliasKey, transliterate(aliasKey)); aliasRank >= 0 {
+				if current, ok := aliasRanks[target]; !ok || aliasRank < current {
+					aliasRanks[target] = aliasRank
+				}
+			}
+		}
+	}
+
+	type found struct {
+		entry cityEntry
+		rank  int
+	}
+	var matches []found
+	for _, entry := range r.entries {
+		if search.Domain != "" && entry.domain != search.Domain {
+			continue
+		}
+
+		entryRank := rank(entry.key, entry.latin)
+		if aliasRank, ok := aliasRanks[entry.key]; ok && (entryRank < 0 || aliasRank < entryRank) {
+			entryRank = aliasRank
+		}
+		if entryRank >= 0 {
+			matches = append(matches, found{entry: entry, rank: entryRank})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.entry.name != b.entry.name {
+			return a.entry.name < b.entry.name
+		}
+		return a.entry.domain == "com" && b.entry.domain != "com"
+	})
+
+	cities := make([]City, 0, len(matches))
+	for _, match := range matches {
+		cities = append(cities, match.entry.city())
+	}
+	return cities
+}
+
+// CitiesNamed возвращает город с названием name из каждого каталога, где он есть
+func CitiesNamed(name string) []City {
+	r := resolver.Load()
+	key := NormalizeCityName(name)
+
+	var cities []City
+	for _, entry := range r.entries {
+		if entry.key == key {
+			cities = append(cities, entry.city())
+		}
+	}
+	return cities
+}
+
+func (e cityEntry) city() City {
+	return City{
+		ID:     e.id,
+		Name:   e.name,
+		Domain: e.domain,
+		Slug:   CitySlug(e.domain, e.id),
+	}
+}
@@ -0,0 +1,138 @@
+package WeGoTrip
+
+//go:generate go run ../../cmd/gencities -overrides cities_overrides.yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Cities - таблицы городов app.wegotrip.com и wegotrip.ru
+// (название в нижнем регистре -> ID) и алиасы названий
+type Cities struct {
+	COM     map[string]int    `yaml:"com"`
+	RU      map[string]int    `yaml:"ru"`
+	Aliases map[string]string `yaml:"aliases"`
+}
+
+// resolver - поиск по таблицам, которые действуют сейчас
+var resolver atomic.Pointer[Resolver]
+
+func init() {
+	SetCities(DefaultCities())
+}
+
+// DefaultCities возвращает встроенные в сервис таблицы городов
+func DefaultCities() *Cities {
+	return &Cities{
+		COM:     COMWeGoTripCities,
+		RU:      RUWeGoTripCities,
+		Aliases: DefaultAliases,
+	}
+}
+
+// LoadCities читает YAML с городами и накладывает его на встроенные таблицы.
+// Города из файла добавляются или заменяют встроенные; ID 0 удаляет город.
+// Алиасы дополняют DefaultAliases, пустое значение удаляет алиас.
+func LoadCities(path string) (*Cities, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file Cities
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	defaults := DefaultCities()
+	com, comErr := mergeCities("com", defaults.COM, file.COM)
+	ru, ruErr := mergeCities("ru", defaults.RU, file.RU)
+	aliases, aliasErr := mergeAliases(defaults.Aliases, file.Aliases, com, ru)
+	if err := errors.Join(comErr, ruErr, aliasErr); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &Cities{COM: com, RU: ru, Aliases: aliases}, nil
+}
+
+// SetCities атомарно заменяет таблицы городов и перестраивает поиск по ним
+func SetCities(c *Cities) {
+	resolver.Store(NewResolver(c))
+}
+
+// currentCities возвращает таблицы городов, действующие на момент вызова
+func currentCities() *Cities {
+	return resolver.Load().cities
+}
+
+// ResolveCity ищет город по свободно введенному названию в действующих таблицах
+func ResolveCity(name string, minSimilarity float64) (Match, bool) {
+	return resolver.Load().Resolve(name, minSimilarity)
+}
+
+// SuggestCities возвращает до limit городов действующих таблиц, похожих на name
+func SuggestCities(name string, limit int) []string {
+	return resolver.Load().Suggest(name, limit)
+}
+
+// mergeCities копирует встроенную таблицу и применяет к ней города из файла
+func mergeCities(domain string, base, overrides map[string]int) (map[string]int, error) {
+	merged := make(map[string]int, len(base)+len(overrides))
+	for name, id := range base {
+		merged[name] = id
+	}
+
+	var errs []error
+	for _, name := range sortedNames(overrides) {
+		id := overrides[name]
+		key := strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case key == "":
+			errs = append(errs, fmt.Errorf("%s: пустое название города", domain))
+		case id < 0:
+			errs = append(errs, fmt.Errorf("%s.%s: ID не может быть отрицательным", domain, name))
+		case id == 0:
+			delete(merged, key)
+		default:
+			merged[key] = id
+		}
+	}
+
+	return merged, errors.Join(errs...)
+}
+
+// mergeAliases накладывает алиасы из файла на встроенные; алиас должен
+// указывать на город из таблиц com или ru
+func mergeAliases(base, overrides map[string]string, com, ru map[string]int) (map[string]string, error) {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for alias, name := range base {
+		merged[alias] = name
+	}
+
+	var errs []error
+	for _, alias := range sortedNames(overrides) {
+		name := strings.ToLower(strings.TrimSpace(overrides[alias]))
+		switch {
+		case NormalizeCityName(alias) == "":
+			errs = append(errs, fmt.Errorf("aliases: пустой алиас"))
+		case name == "":
+			delete(merged, alias)
+		case com[name] == 0 && ru[name] == 0:
+			errs = append(errs, fmt.Errorf("aliases.%s: нет города %q", alias, name))
+		default:
+			merged[alias] = name
+		}
+	}
+
+	return merged, errors.Join(errs...)
+}
@@ -0,0 +1,409 @@
+package WeGoTrip
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultMinSimilarity - наименьшее сходство (0..1), при котором город
+// находится по расстоянию редактирования
+const DefaultMinSimilarity = 0.75
+
+// SuggestionMinSimilarity - наименьшее сходство, при котором город
+// предлагается как вариант для ненайденного названия
+const SuggestionMinSimilarity = 0.4
+
+// Ограничения запроса подсказок: название приходит от пользователя, а каждое
+// слово сравнивается со всем каталогом, поэтому длина и число слов ограничены
+const (
+	maxSuggestionQueryLength = 64
+	maxSuggestionWords       = 4
+)
+
+// Способы, которыми найден город
+const (
+	MatchExact           = "exact"
+	MatchNormalized      = "normalized"
+	MatchAlias           = "alias"
+	MatchTransliteration = "transliteration"
+	MatchFuzzy           = "fuzzy"
+)
+
+// Match - город, найденный по введенному пользователем названию
+type Match struct {
+	ID int
+	// Name - название из таблицы городов, его бот может показать для подтверждения
+	Name   string
+	Domain string
+	// Method - способ, которым найден город (MatchExact, MatchAlias, ...)
+	Method string
+	// Similarity - сходство введенного названия с найденным, 1 - полное совпадение
+	Similarity float64
+}
+
+// cityEntry - город таблицы с ключами для поиска
+type cityEntry struct {
+	name   string
+	id     int
+	domain string
+	key    string
+	latin  string
+}
+
+// Resolver ищет города по свободно введенному названию: с любым регистром,
+// знаками препинания, ё, диакритикой, латиницей вместо кириллицы, по алиасам
+// и с опечатками
+type Resolver struct {
+	cities  *Cities
+	entries []cityEntry
+	// normalized и latin - индексы записей по нормализованному названию
+	// и по его латинской транслитерации без пробелов
+	normalized map[string]int
+	latin      map[string]int
+	// aliases - записи, на которые указывают алиасы, по нормализованному
+	// алиасу и по его транслитерации
+	aliases      map[string]int
+	latinAliases map[string]int
+}
+
+// NewResolver строит индексы по таблицам городов. Город, который есть в обоих
+// каталогах, находится в app.wegotrip.com, как и при точном поиске.
+func NewResolver(c *Cities) *Resolver {
+	r := &Resolver{
+		cities:       c,
+		normalized:   make(map[string]int, len(c.COM)+len(c.RU)),
+		latin:        make(map[string]int, len(c.COM)+len(c.RU)),
+		aliases:      make(map[string]int, len(c.Aliases)),
+		latinAliases: make(map[string]int, len(c.Aliases)),
+	}
+
+	for _, table := range []struct {
+		domain string
+		cities map[string]int
+	}{{"com", c.COM}, {"ru", c.RU}} {
+		for _, name := range sortedNames(table.cities) {
+			key := NormalizeCityName(name)
+			entry := cityEntry{
+				name:   name,
+				id:     table.cities[name],
+				domain: table.domain,
+				key:    key,
+				latin:  transliterate(key),
+			}
+			r.entries = append(r.entries, entry)
+
+			index := len(r.entries) - 1
+			if _, ok := r.normalized[key]; !ok {
+				r.normalized[key] = index
+			}
+			if _, ok := r.latin[entry.latin]; !ok {
+				r.latin[entry.latin] = index
+			}
+		}
+	}
+
+	for _, alias := range sortedNames(c.Aliases) {
+		target, ok := r.normalized[NormalizeCityName(c.Aliases[alias])]
+		if !ok {
+			continue
+		}
+		key := NormalizeCityName(alias)
+		if _, ok := r.aliases[key]; !ok {
+			r.aliases[key] = target
+		}
+		if latin := transliterate(key); latin != "" {
+			if _, ok := r.latinAliases[latin]; !ok {
+				r.latinAliases[latin] = target
+			}
+		}
+	}
+
+	return r
+}
+
+// Resolve находит город по названию. Сначала ищется точное совпадение,
+// затем нормализованное название, алиас, транслитерация и, наконец, самое
+// похожее название со сходством не меньше minSimilarity.
+func (r *Resolver) Resolve(name string, minSimilarity float64) (Match, bool) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if id := r.cities.COM[lower]; id != 0 {
+		return Match{ID: id, Name: lower, Domain: "com", Method: MatchExact, Similarity: 1}, true
+	}
+	if id := r.cities.RU[lower]; id != 0 {
+		return Match{ID: id, Name: lower, Domain: "ru", Method: MatchExact, Similarity: 1}, true
+	}
+
+	key := NormalizeCityName(name)
+	if key == "" {
+		return Match{}, false
+	}
+	if index, ok := r.normalized[key]; ok {
+		return r.match(index, MatchNormalized, 1), true
+	}
+	if index, ok := r.aliases[key]; ok {
+		return r.match(index, MatchAlias, 1), true
+	}
+
+	latin := transliterate(key)
+	if index, ok := r.latin[latin]; ok {
+		return r.match(index, MatchTransliteration, 1), true
+	}
+	if index, ok := r.latinAliases[latin]; ok {
+		return r.match(index, MatchAlias, 1), true
+	}
+
+	if index, similarity, ok := r.closest(latin, minSimilarity); ok {
+		return r.match(index, MatchFuzzy, similarity), true
+	}
+
+	return Match{}, false
+}
+
+func (r *Resolver) match(index int, method string, similarity float64) Match {
+	entry := r.entries[index]
+	return Match{
+		ID:         entry.id,
+		Name:       entry.name,
+		Domain:     entry.domain,
+		Method:     method,
+		Similarity: similarity,
+	}
+}
+
+// closest возвращает запись, чье название или алиас ближе всего к latin.
+// При равном сходстве побеждает запись, встретившаяся раньше, поэтому
+// результат не зависит от порядка обхода map.
+func (r *Resolver) closest(latin string, minSimilarity float64) (int, float64, bool) {
+	best, bestSimilarity := -1, 0.0
+	consider := func(candidate string, index int) {
+		similarity := stringSimilarity(latin, candidate, minSimilarity)
+		if similarity >= minSimilarity && similarity > bestSimilarity {
+			best, bestSimilarity = index, similarity
+		}
+	}
+
+	for index, entry := range r.entries {
+		consider(entry.latin, index)
+	}
+	for _, alias := range sortedNames(r.latinAliases) {
+		consider(alias, r.latinAliases[alias])
+	}
+
+	return best, bestSimilarity, best >= 0
+}
+
+// Suggest возвращает до limit названий городов, наиболее похожих на name,
+// от самого похожего. Город из обоих каталогов и его алиасы дают один
+// вариант; при равном сходстве порядок алфавитный (сначала app.wegotrip.com),
+// так что для одних таблиц результат всегда одинаков.
+func (r *Resolver) Suggest(name string, limit int) []string {
+	if runes := []rune(name); len(runes) > maxSuggestionQueryLength {
+		name = string(runes[:maxSuggestionQueryLength])
+	}
+	key := NormalizeCityName(name)
+	if key == "" || limit <= 0 {
+		return nil
+	}
+
+	// Кроме названия целиком сравниваются отдельные слова: "Лондн Англия"
+	queries := []string{transliterate(key)}
+	if words := strings.Fields(key); len(words) > 1 {
+		if len(words) > maxSuggestionWords {
+			words = words[:maxSuggestionWords]
+		}
+		for _, word := range words {
+			queries = append(queries, transliterate(word))
+		}
+	}
+
+	scores := make(map[int]float64)
+	consider := func(candidate string, index int) {
+		for _, query := range queries {
+			similarity := suggestionSimilarity(query, candidate)
+			if similarity >= SuggestionMinSimilarity && similarity > scores[index] {
+				scores[index] = similarity
+			}
+		}
+	}
+
+	for _, entry := range r.entries {
+		consider(entry.latin, r.normalized[entry.key])
+	}
+	for _, alias := range sortedNames(r.latinAliases) {
+		consider(alias, r.latinAliases[alias])
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for index := range scores {
+		ranked = append(ranked, index)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	suggestions := make([]string, 0, len(ranked))
+	for _, index := range ranked {
+		suggestions = append(suggestions, r.entries[index].name)
+	}
+	return suggestions
+}
+
+// suggestionSimilarity - сходство для подсказок: начало названия
+// ("санкт" для "санкт-петербург") ценится выше, чем по расстоянию редактирования
+func suggestionSimilarity(query, candidate string) float64 {
+	// Названия, заведомо далекие по длине, не сравниваются: они не наберут порога
+	similarity := stringSimilarity(query, candidate, SuggestionMinSimilarity)
+
+	queryLength, candidateLength := len([]rune(query)), len([]rune(candidate))
+	if queryLength >= 3 && strings.HasPrefix(candidate, query) {
+		similarity = max(similarity, 0.5+0.5*float64(queryLength)/float64(candidateLength))
+	}
+
+	return similarity
+}
+
+// NormalizeCityName приводит название к виду для сравнения: нижний регистр,
+// ё -> е, латиница без диакритики, вместо знаков препинания и повторных
+// пробелов - один пробел
+func NormalizeCityName(name string) string {
+	var b strings.Builder
+	space := false
+
+	write := func(r rune) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+			return
+		}
+		if !unicode.Is(unicode.Mn, r) {
+			space = true
+		}
+	}
+
+	for _, r := range strings.ToLower(name) {
+		if r == 'ё' {
+			r = 'е'
+		}
+		if unicode.Is(unicode.Cyrillic, r) {
+			write(r)
+			continue
+		}
+		if folded, ok := latinFolding[r]; ok {
+			for _, f := range folded {
+				write(f)
+			}
+			continue
+		}
+		for _, d := range norm.NFD.String(string(r)) {
+			write(d)
+		}
+	}
+
+	return b.String()
+}
+
+// latinFolding - латинские буквы, которые не раскладываются на букву и диакритику
+var latinFolding = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'ł': "l",
+	'đ': "d",
+	'ð': "d",
+	'þ': "th",
+	'ı': "i",
+}
+
+// cyrillicToLatin - транслитерация кириллицы, близкая к принятой в загранпаспортах
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n",
+	'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// transliterate переводит нормализованное название в латиницу без пробелов,
+// чтобы "Moskva", "Москва" и "mos kva" сравнивались одинаково
+func transliterate(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if r == ' ' {
+			continue
+		}
+		if latin, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// stringSimilarity - 1 минус расстояние Левенштейна, деленное на длину
+// более длинной строки. Строки, которые заведомо не наберут minSimilarity
+// по разнице длин, не сравниваются.
+func stringSimilarity(a, b string, minSimilarity float64) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	diff := len(ra) - len(rb)
+	if diff < 0 {
+		diff = -diff
+	}
+	if 1-float64(diff)/float64(longest) < minSimilarity {
+		return 0
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein - расстояние редактирования между a и b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// sortedNames возвращает ключи map в алфавитном порядке
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
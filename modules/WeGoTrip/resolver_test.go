@@ -0,0 +1,162 @@
+package WeGoTrip
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testResolver - небольшой каталог, чтобы ожидаемый порядок не зависел от встроенных таблиц
+func testResolver() *Resolver {
+	return NewResolver(&Cities{
+		COM: map[string]int{
+			"париж": 3, "рим": 2, "прага": 21, "барселона": 1,
+			"берлин": 4, "бергамо": 5, "кана": 70, "каса": 71,
+		},
+		RU:      map[string]int{"прага": 21, "пермь": 60, "пенза": 61},
+		Aliases: map[string]string{"рома": "рим"},
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	resolver := testResolver()
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "пустой запрос", query: "", limit: 3, want: nil},
+		{name: "только пробелы", query: "   ", limit: 3, want: nil},
+		{name: "нулевой лимит", query: "Бер", limit: 0, want: nil},
+		{name: "начало названия", query: "Бер", limit: 3, want: []string{"берлин", "бергамо", "пермь"}},
+		{name: "лимит обрезает список", query: "Бер", limit: 2, want: []string{"берлин", "бергамо"}},
+		{name: "равное сходство - по алфавиту", query: "кала", limit: 3, want: []string{"кана", "каса", "прага"}},
+		{name: "город из двух каталогов - один вариант", query: "Праха", limit: 3, want: []string{"прага"}},
+		{name: "лишнее слово", query: "Барселна Испания", limit: 3, want: []string{"барселона"}},
+		{name: "алиас дает свой город", query: "ромa", limit: 3, want: []string{"рим", "прага"}},
+		{name: "ничего похожего", query: "zzzz", limit: 3, want: []string{}},
+		{name: "много слов", query: strings.Repeat("рим ", 100), limit: 3, want: []string{"рим"}},
+		{name: "длинный запрос", query: strings.Repeat("я", 10000), limit: 3, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolver.Suggest(tt.query, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Suggest(%.20q, %d) = %q, ожидалось %q", tt.query, tt.limit, got, tt.want)
+			}
+
+			// Повторный вызов дает тот же порядок
+			if again := resolver.Suggest(tt.query, tt.limit); !reflect.DeepEqual(again, got) {
+				t.Fatalf("повторный Suggest(%.20q) = %q, первый %q", tt.query, again, got)
+			}
+		})
+	}
+}
+
+func TestSuggestQueryIsCapped(t *testing.T) {
+	resolver := testResolver()
+
+	long := "Праха" + strings.Repeat(" x", 1000)
+	capped := string([]rune(long)[:maxSuggestionQueryLength])
+	if got, want := resolver.Suggest(long, 3), resolver.Suggest(capped, 3); !reflect.DeepEqual(got, want) {
+		t.Fatalf("длинный запрос: %q, первые %d символов: %q", got, maxSuggestionQueryLength, want)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	resolver := NewResolver(DefaultCities())
+
+	tests := []struct {
+		query  string
+		want   string
+		method string
+	}{
+		{query: "санкт-петербург", want: "санкт-петербург", method: MatchExact},
+		{query: "  САНКТ-ПЕТЕРБУРГ  ", want: "санкт-петербург", method: MatchExact},
+		{query: "Санкт Петербург", want: "санкт-петербург", method: MatchNormalized},
+		{query: "spb", want: "санкт-петербург", method: MatchAlias},
+		{query: "St. Petersburg", want: "санкт-петербург", method: MatchAlias},
+		{query: "Питер", want: "санкт-петербург", method: MatchAlias},
+		{query: "Мск", want: "москва", method: MatchAlias},
+		{query: "Moskva", want: "москва", method: MatchTransliteration},
+		// Опечатка в одну букву
+		{query: "Масква", want: "москва", method: MatchFuzzy},
+		{query: "Барселна", want: "барселона", method: MatchFuzzy},
+		// Сходство ниже DefaultMinSimilarity: город не найден
+		{query: "Брслн"},
+		{query: "Лондон Англия"},
+		{query: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			match, ok := resolver.Resolve(tt.query, DefaultMinSimilarity)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("Resolve(%q) = %+v, ожидалось, что город не найдется", tt.query, match)
+				}
+				return
+			}
+
+			if !ok || match.Name != tt.want || match.Method != tt.method {
+				t.Fatalf("Resolve(%q) = %+v, %v; ожидалось %s (%s)", tt.query, match, ok, tt.want, tt.method)
+			}
+			if tt.method == MatchFuzzy && (match.Similarity < DefaultMinSimilarity || match.Similarity >= 1) {
+				t.Fatalf("Resolve(%q): сходство %v", tt.query, match.Similarity)
+			}
+		})
+	}
+}
+
+func TestNormalizeCityName(t *testing.T) {
+	tests := map[string]string{
+		"Санкт-Петербург": "санкт петербург",
+		"Ёлки, палки!":    "елки палки",
+		"São Paulo":       "sao paulo",
+		"  New   York ":   "new york",
+		"St. Petersburg":  "st petersburg",
+		"":                "",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeCityName(input); got != want {
+			t.Errorf("NormalizeCityName(%q) = %q, ожидалось %q", input, got, want)
+		}
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := map[string]string{
+		"санкт петербург": "sanktpeterburg",
+		"москва":          "moskva",
+		"new york":        "newyork",
+	}
+
+	for input, want := range tests {
+		if got := transliterate(input); got != want {
+			t.Errorf("transliterate(%q) = %q, ожидалось %q", input, got, want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"москва", "масква", 1},
+		{"рим", "рим", 0},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, ожидалось %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -2,17 +2,61 @@ package WeGoTrip
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"tp-go-service/modules"
+	"tp-go-service/modules/Tracing"
 )
 
+// WeGoTrip - клиент WeGoTrip API, один на все запросы сервиса
 type WeGoTrip struct {
-	client *http.Client
+	client  *http.Client
+	options atomic.Pointer[Options]
+}
+
+// Options - адреса WeGoTrip и параметры подборки по умолчанию
+type Options struct {
+	COMBaseURL string
+	RUBaseURL  string
+	// COMSiteURL и RUSiteURL - публичные сайты для ссылок на экскурсии;
+	// API может быть за прокси, а ссылки уходят пользователям
+	COMSiteURL string
+	RUSiteURL  string
+	// PageSize - экскурсий на странице, если запрос не задал page_size
+	PageSize int
+	// MaxPageSize - наибольший page_size, который можно запросить
+	MaxPageSize int
+	// MinSimilarity - наименьшее сходство названия города для поиска с опечатками
+	MinSimilarity float64
+	// CitySuggestions - сколько похожих городов предлагать, если город не найден;
+	// 0 - не предлагать
+	CitySuggestions int
+	DefaultLang     string
+	DefaultCurrency string
+}
+
+// DefaultOptions - параметры, с которыми подборка работала исторически
+func DefaultOptions() Options {
+	return Options{
+		COMBaseURL:      "https://app.wegotrip.com",
+		RUBaseURL:       "https://wegotrip.ru",
+		COMSiteURL:      "https://app.wegotrip.com",
+		RUSiteURL:       "https://wegotrip.ru",
+		PageSize:        3,
+		MaxPageSize:     10,
+		MinSimilarity:   DefaultMinSimilarity,
+		CitySuggestions: 3,
+		DefaultLang:     "RU",
+		DefaultCurrency: "RUB",
+	}
 }
 
 type WeGoTripError struct {
@@ -28,6 +72,30 @@ func NewWeGoTripError(code, message string) modules.APIError {
 	}
 }
 
+// CityNotFoundError - город не найден; Suggestions - похожие названия
+// из каталога, которые бот может предложить пользователю
+type CityNotFoundError struct {
+	WeGoTripError
+	Suggestions []string
+}
+
+func NewCityNotFoundError(suggestions []string) modules.APIError {
+	message := "нет такого города"
+	if len(suggestions) > 0 {
+		message += ". Возможно, вы имели в виду: " + strings.Join(suggestions, ", ")
+	}
+
+	return &CityNotFoundError{
+		WeGoTripError: WeGoTripError{
+			BaseError: modules.BaseError{
+				Code:    "city_not_found",
+				Message: message,
+			},
+		},
+		Suggestions: suggestions,
+	}
+}
+
 type WeGoTripProduct struct {
 	ID    int          `json:"id"`
 	Title string       `json:"title"`
@@ -63,6 +131,31 @@ type WeGoTripErrorResponse struct {
 	Errors []WeGoTripErrorStruct `json:"errors"`
 }
 
+// FeedQuery - параметры запроса подборки; пустые поля заменяются значениями по умолчанию
+type FeedQuery struct {
+	City     string
+	Lang     string
+	Currency string
+	Page     int
+	PageSize int
+}
+
+// Feed - страница подборки с данными для навигации по страницам.
+// NextPage равен 0, если следующей страницы нет.
+type Feed struct {
+	// City - город, найденный по названию из запроса
+	City Match
+	// CitySlug - slug города из ответа API, пустой, если экскурсий нет
+	CitySlug   string
+	Items      []FeedItem
+	Page       int
+	PageSize   int
+	TotalItems int
+	TotalPages int
+	HasNext    bool
+	NextPage   int
+}
+
 type FeedItem struct {
 	ID       int     `json:"id"`
 	Title    string  `json:"title"`
@@ -73,58 +166,144 @@ type FeedItem struct {
 	Link     string  `json:"link"`
 }
 
-func New() *WeGoTrip {
-	return &WeGoTrip{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// New создает клиент WeGoTrip API; при client == nil используется
+// клиент с повторными запросами по умолчанию
+func New(client *http.Client, options Options) *WeGoTrip {
+	if client == nil {
+		client = modules.NewHTTPClient(http.DefaultTransport, modules.DefaultRetryConfig())
 	}
+
+	wg := &WeGoTrip{client: client}
+	wg.SetOptions(options)
+	return wg
+}
+
+// SetOptions атомарно заменяет параметры клиента; незаданные поля
+// берутся из DefaultOptions
+func (wg *WeGoTrip) SetOptions(options Options) {
+	defaults := DefaultOptions()
+	if options.COMBaseURL == "" {
+		options.COMBaseURL = defaults.COMBaseURL
+	}
+	if options.RUBaseURL == "" {
+		options.RUBaseURL = defaults.RUBaseURL
+	}
+	if options.COMSiteURL == "" {
+		options.COMSiteURL = defaults.COMSiteURL
+	}
+	if options.RUSiteURL == "" {
+		options.RUSiteURL = defaults.RUSiteURL
+	}
+	if options.PageSize <= 0 {
+		options.PageSize = defaults.PageSize
+	}
+	if options.MaxPageSize <= 0 {
+		options.MaxPageSize = defaults.MaxPageSize
+	}
+	if options.MaxPageSize < options.PageSize {
+		options.MaxPageSize = options.PageSize
+	}
+	if options.MinSimilarity <= 0 {
+		options.MinSimilarity = defaults.MinSimilarity
+	}
+	if options.DefaultLang == "" {
+		options.DefaultLang = defaults.DefaultLang
+	}
+	if options.DefaultCurrency == "" {
+		options.DefaultCurrency = defaults.DefaultCurrency
+	}
+	options.COMBaseURL = strings.TrimRight(options.COMBaseURL, "/")
+	options.RUBaseURL = strings.TrimRight(options.RUBaseURL, "/")
+	options.COMSiteURL = strings.TrimRight(options.COMSiteURL, "/")
+	options.RUSiteURL = strings.TrimRight(options.RUSiteURL, "/")
+
+	wg.options.Store(&options)
+}
+
+func (wg *WeGoTrip) GetFeed(query FeedQuery) (Feed, modules.APIError) {
+	return wg.GetFeedContext(context.Background(), query)
+}
+
+// GetFeedContext возвращает страницу подборки, прерывая запрос при отмене ctx
+func (wg *WeGoTrip) GetFeedContext(ctx context.Context, query FeedQuery) (Feed, modules.APIError) {
+	ctx, span := Tracing.Tracer().Start(ctx, "WeGoTrip.GetFeed", trace.WithAttributes(
+		attribute.String("wegotrip.city", query.City),
+		attribute.String("wegotrip.lang", query.Lang),
+		attribute.String("wegotrip.currency", query.Currency),
+		attribute.Int("wegotrip.page", query.Page),
+		attribute.Int("wegotrip.page_size", query.PageSize),
+	))
+
+	feed, err := wg.getFeed(ctx, query)
+	span.SetAttributes(
+		attribute.Int("wegotrip.items", len(feed.Items)),
+		attribute.Int("wegotrip.total_items", feed.TotalItems),
+	)
+
+	Tracing.End(span, err)
+	return feed, err
 }
 
-func (wg *WeGoTrip) GetFeed(city, lang, currency string, page int) ([]FeedItem, modules.APIError) {
+func (wg *WeGoTrip) getFeed(ctx context.Context, query FeedQuery) (Feed, modules.APIError) {
+	options := wg.options.Load()
+
+	lang := query.Lang
 	if lang == "" {
-		lang = "RU"
+		lang = options.DefaultLang
 	}
+	currency := query.Currency
 	if currency == "" {
-		currency = "RUB"
+		currency = options.DefaultCurrency
 	}
+	page := query.Page
 	if page <= 0 {
 		page = 1
 	}
-
-	cityID := 0
-	domain := ""
-
-	if cityID = GetCOMWeGoTripCityID(city); cityID != 0 {
-		domain = "com"
-	} else if cityID = GetRUWeGoTripCityID(city); cityID != 0 {
-		domain = "ru"
+	pageSize := query.PageSize
+	if pageSize == 0 {
+		pageSize = options.PageSize
+	}
+	if pageSize < 1 || pageSize > options.MaxPageSize {
+		return Feed{}, NewWeGoTripError("invalid_page_size", fmt.Sprintf("page_size должен быть от 1 до %d", options.MaxPageSize))
 	}
 
-	if cityID == 0 {
-		return nil, NewWeGoTripError("city_not_found", "нет такого города")
+	city, cityErr := lookupCity(ctx, query.City, options.MinSimilarity, options.CitySuggestions)
+	if cityErr != nil {
+		return Feed{}, cityErr
 	}
+	cityID := city.ID
 
-	var baseURL string
-	if domain == "ru" {
-		baseURL = "https://wegotrip.ru"
-	} else {
-		baseURL = "https://app.wegotrip.com"
+	baseURL, siteURL := options.COMBaseURL, options.COMSiteURL
+	if city.Domain == "ru" {
+		baseURL, siteURL = options.RUBaseURL, options.RUSiteURL
 	}
 
-	requestURL := fmt.Sprintf("%s/api/v2/products/popular/?city=%d&lang=%s&currency=%s",
-		baseURL, cityID, strings.ToLower(lang), currency)
+	requestURL := fmt.Sprintf("%s/api/v2/products/popular/?city=%d&lang=%s&currency=%s&page=%d&page_size=%d",
+		baseURL, cityID, strings.ToLower(lang), currency, page, pageSize)
 
-	resp, err := wg.client.Get(requestURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
-		return nil, NewWeGoTripError("network_error", "ошибка запроса к WeGoTrip API")
+		return Feed{}, NewWeGoTripError("request_error", "ошибка создания запроса")
+	}
+
+	resp, err := wg.client.Do(req)
+	if err != nil {
+		return Feed{}, requestError(err)
 	}
 	defer resp.Body.Close()
 
 	var responseBody bytes.Buffer
 	_, err = responseBody.ReadFrom(resp.Body)
 	if err != nil {
-		return nil, NewWeGoTripError("response_error", "ошибка чтения ответа")
+		if code := modules.UpstreamErrorCode(err); code == "deadline_exceeded" || code == "request_canceled" {
+			return Feed{}, requestError(err)
+		}
+		return Feed{}, NewWeGoTripError("response_error", "ошибка чтения ответа")
+	}
+
+	if resp.StatusCode == http.StatusNotFound && page > 1 {
+		// Страницы за пределами подборки API отдает как 404
+		return Feed{}, pageOutOfRange(page, 0)
 	}
 
 	responseBytes := responseBody.Bytes()
@@ -132,48 +311,34 @@ func (wg *WeGoTrip) GetFeed(city, lang, currency string, page int) ([]FeedItem,
 	var errorResponse WeGoTripErrorResponse
 	if err := json.Unmarshal(responseBytes, &errorResponse); err == nil && len(errorResponse.Errors) > 0 {
 		errorMessage := errorResponse.Errors[0].Message
-		return nil, NewWeGoTripError("wegotrip_api_error", errorMessage)
+		return Feed{}, NewWeGoTripError("wegotrip_api_error", errorMessage)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, NewWeGoTripError("api_error", fmt.Sprintf("API вернул ошибку %d", resp.StatusCode))
+		return Feed{}, NewWeGoTripError("api_error", fmt.Sprintf("API вернул ошибку %d", resp.StatusCode))
 	}
 
 	var apiResponse WeGoTripResponse
 	if err := json.Unmarshal(responseBytes, &apiResponse); err != nil {
-		return nil, NewWeGoTripError("parse_error", "ошибка парсинга ответа")
-	}
-
-	results := apiResponse.Data.Results
-	totalItems := len(results)
-
-	startIndex := (page - 1) * 3
-	endIndex := startIndex + 3
-
-	if startIndex >= totalItems {
-		return []FeedItem{}, nil
+		return Feed{}, NewWeGoTripError("parse_error", "ошибка парсинга ответа")
 	}
 
-	if endIndex > totalItems {
-		endIndex = totalItems
+	feed, products := paginate(apiResponse.Data, page, pageSize)
+	feed.City = city
+	if page > 1 && page > feed.TotalPages {
+		return Feed{}, pageOutOfRange(page, feed.TotalPages)
 	}
 
-	paginatedResults := results[startIndex:endIndex]
-
-	var feedItems []FeedItem
-	for _, product := range paginatedResults {
-
-		var linkDomain string
-		if domain == "ru" {
-			linkDomain = "wegotrip.ru"
-		} else {
-			linkDomain = "app.wegotrip.com"
+	feed.Items = make([]FeedItem, 0, len(products))
+	for _, product := range products {
+		if feed.CitySlug == "" {
+			feed.CitySlug = product.City.Slug
 		}
 
-		link := fmt.Sprintf("https://%s/%s-d%d/%s-p%d",
-			linkDomain, product.City.Slug, cityID, product.Slug, product.ID)
+		link := fmt.Sprintf("%s/%s-d%d/%s-p%d",
+			siteURL, product.City.Slug, cityID, product.Slug, product.ID)
 
-		feedItem := FeedItem{
+		feed.Items = append(feed.Items, FeedItem{
 			ID:       product.ID,
 			Title:    product.Title,
 			Slug:     product.Slug,
@@ -181,10 +346,101 @@ func (wg *WeGoTrip) GetFeed(city, lang, currency string, page int) ([]FeedItem,
 			Price:    product.Price,
 			Cover:    product.Cover,
 			Link:     link,
+		})
+	}
+
+	return feed, nil
+}
+
+// paginate возвращает страницу page по pageSize экскурсий и ее продукты. Если API
+// ответил постранично (в ответе есть current или pages), страница берется из ответа
+// целиком вместе с count и pages - даже если API не учел page_size и отдал страницы
+// своего размера. Иначе API вернул подборку целиком, и страница вырезается из нее
+// на нашей стороне. Два способа не смешиваются: иначе страница API резалась бы
+// повторно и часть экскурсий пропадала.
+func paginate(data WeGoTripData, page, pageSize int) (Feed, []WeGoTripProduct) {
+	feed := Feed{Page: page, PageSize: pageSize}
+	var products []WeGoTripProduct
+
+	if data.Current > 0 || data.Pages > 0 {
+		products = data.Results
+		feed.PageSize = max(pageSize, len(data.Results))
+		feed.TotalItems = max(data.Count, len(data.Results))
+		feed.TotalPages = data.Pages
+	} else {
+		feed.TotalItems = len(data.Results)
+		start := (page - 1) * pageSize
+		if start < len(data.Results) {
+			end := min(start+pageSize, len(data.Results))
+			products = data.Results[start:end]
 		}
+	}
+
+	if feed.TotalPages <= 0 {
+		feed.TotalPages = (feed.TotalItems + feed.PageSize - 1) / feed.PageSize
+	}
+	if page < feed.TotalPages {
+		feed.HasNext = true
+		feed.NextPage = page + 1
+	}
+
+	return feed, products
+}
 
-		feedItems = append(feedItems, feedItem)
+// pageOutOfRange - ошибка запроса страницы за пределами подборки; total 0 - число страниц неизвестно
+func pageOutOfRange(page, total int) modules.APIError {
+	if total <= 0 {
+		return NewWeGoTripError("page_out_of_range", fmt.Sprintf("страницы %d нет в подборке", page))
+	}
+	return NewWeGoTripError("page_out_of_range", fmt.Sprintf("страницы %d нет в подборке, всего страниц: %d", page, total))
+}
+
+// CityCounts возвращает количество городов в каталогах app.wegotrip.com и wegotrip.ru
+func CityCounts() (com, ru int) {
+	current := currentCities()
+	return len(current.COM), len(current.RU)
+}
+
+// lookupCity ищет город сначала в каталоге app.wegotrip.com, затем в wegotrip.ru,
+// допуская опечатки со сходством не меньше minSimilarity. Если город не найден,
+// ошибка содержит до suggestions похожих названий.
+func lookupCity(ctx context.Context, city string, minSimilarity float64, suggestions int) (Match, modules.APIError) {
+	_, span := Tracing.Tracer().Start(ctx, "WeGoTrip.LookupCity", trace.WithAttributes(
+		attribute.String("wegotrip.city", city),
+	))
+
+	match, ok := ResolveCity(city, minSimilarity)
+	if !ok {
+		similar := SuggestCities(city, suggestions)
+		span.SetAttributes(attribute.StringSlice("wegotrip.city_suggestions", similar))
+
+		err := NewCityNotFoundError(similar)
+		Tracing.End(span, err)
+		return Match{}, err
 	}
 
-	return feedItems, nil
+	span.SetAttributes(
+		attribute.Int("wegotrip.city_id", match.ID),
+		attribute.String("wegotrip.domain", match.Domain),
+		attribute.String("wegotrip.city_name", match.Name),
+		attribute.String("wegotrip.city_match", match.Method),
+		attribute.Float64("wegotrip.city_similarity", match.Similarity),
+	)
+	span.End()
+
+	return match, nil
+}
+
+// requestError переводит ошибку HTTP-запроса в ошибку API сервиса
+func requestError(err error) modules.APIError {
+	switch code := modules.UpstreamErrorCode(err); code {
+	case "upstream_unavailable":
+		return NewWeGoTripError(code, "WeGoTrip API временно недоступен")
+	case "deadline_exceeded":
+		return NewWeGoTripError(code, "превышено время ожидания ответа WeGoTrip API")
+	case "request_canceled":
+		return NewWeGoTripError(code, "запрос к WeGoTrip API отменен")
+	default:
+		return NewWeGoTripError(code, "ошибка запроса к WeGoTrip API")
+	}
 }
@@ -0,0 +1,95 @@
+package WeGoTrip
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// feedServer отдает подборку из total экскурсий. Если upstreamPageSize > 0,
+// API отвечает постранично страницами своего размера и не учитывает page_size;
+// иначе на любой запрос возвращает подборку целиком без current и pages.
+func feedServer(t *testing.T, total, upstreamPageSize int) *httptest.Server {
+	t.Helper()
+
+	products := make([]WeGoTripProduct, total)
+	for i := range products {
+		products[i] = WeGoTripProduct{ID: i, Slug: "p" + strconv.Itoa(i), City: WeGoTripCity{Slug: "barcelona"}}
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := WeGoTripData{Count: total, Results: products}
+
+		if upstreamPageSize > 0 {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			pages := (total + upstreamPageSize - 1) / upstreamPageSize
+			if page < 1 || page > pages {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			end := min(page*upstreamPageSize, total)
+			data = WeGoTripData{Count: total, Pages: pages, Current: page, Results: products[(page-1)*upstreamPageSize : end]}
+		}
+
+		json.NewEncoder(w).Encode(WeGoTripResponse{Data: data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetFeedPagination(t *testing.T) {
+	tests := []struct {
+		name             string
+		upstreamPageSize int
+		pageSize         int
+		wantPages        int
+	}{
+		{name: "постраничный ответ своего размера", upstreamPageSize: 12, pageSize: 3, wantPages: 3},
+		{name: "постраничный ответ с page_size", upstreamPageSize: 3, pageSize: 3, wantPages: 10},
+		{name: "подборка целиком", upstreamPageSize: 0, pageSize: 3, wantPages: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := feedServer(t, 30, tt.upstreamPageSize)
+			wg := New(server.Client(), Options{COMBaseURL: server.URL, RUBaseURL: server.URL})
+
+			var seen []int
+			page := 1
+			for page != 0 {
+				feed, err := wg.GetFeed(FeedQuery{City: "барселона", Page: page, PageSize: tt.pageSize})
+				if err != nil {
+					t.Fatalf("страница %d: %v", page, err)
+				}
+				if feed.TotalItems != 30 || feed.TotalPages != tt.wantPages {
+					t.Fatalf("страница %d: total_items=%d total_pages=%d, ожидалось 30 и %d",
+						page, feed.TotalItems, feed.TotalPages, tt.wantPages)
+				}
+				for _, item := range feed.Items {
+					seen = append(seen, item.ID)
+					// Ссылки ведут на публичный сайт, а не на адрес API
+					if want := "https://app.wegotrip.com/barcelona-d1/p" + strconv.Itoa(item.ID) + "-p" + strconv.Itoa(item.ID); item.Link != want {
+						t.Fatalf("ссылка %q, ожидалась %q", item.Link, want)
+					}
+				}
+				page = feed.NextPage
+			}
+
+			if len(seen) != 30 {
+				t.Fatalf("получено %d экскурсий, ожидалось 30: %v", len(seen), seen)
+			}
+			for i, id := range seen {
+				if id != i {
+					t.Fatalf("экскурсия %d на позиции %d: %v", id, i, seen)
+				}
+			}
+
+			_, err := wg.GetFeed(FeedQuery{City: "барселона", Page: tt.wantPages + 1, PageSize: tt.pageSize})
+			if err == nil || err.GetCode() != "page_out_of_range" {
+				t.Fatalf("страница за пределами подборки: %v", err)
+			}
+		})
+	}
+}
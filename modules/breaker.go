@@ -0,0 +1,228 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается без обращения к внешнему API, пока предохранитель разомкнут
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig - параметры предохранителя внешнего API
+type BreakerConfig struct {
+	// FailureThreshold - подряд идущих ошибок до размыкания
+	FailureThreshold int
+	// OpenTimeout - сколько предохранитель остается разомкнутым до пробного запроса
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests - одновременных пробных запросов в полуоткрытом состоянии
+	HalfOpenMaxRequests int
+}
+
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold:    5,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenMaxRequests: 1,
+	}
+}
+
+// BreakerSnapshot - состояние предохранителя для /health
+type BreakerSnapshot struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker - предохранитель (closed/open/half-open) для одного внешнего API
+type CircuitBreaker struct {
+	name   string
+	config BreakerConfig
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	lastError string
+}
+
+func NewCircuitBreaker(name string, config BreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	if config.HalfOpenMaxRequests < 1 {
+		config.HalfOpenMaxRequests = 1
+	}
+	return &CircuitBreaker{
+		name:   name,
+		config: config,
+	}
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// Allow сообщает, можно ли выполнить запрос. После разрешенного запроса
+// обязательно вызывается Success или Failure.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probes = 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probes >= b.config.HalfOpenMaxRequests {
+			return false
+		}
+		b.probes++
+	}
+
+	return true
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probes = 0
+}
+
+func (b *CircuitBreaker) Failure(reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastError = reason
+
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+		b.probes = 0
+	}
+}
+
+// Release освобождает разрешение без учета результата (например, запрос отменен клиентом)
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.config.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	state := b.State()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{
+		Name:      b.name,
+		State:     state.String(),
+		Failures:  b.failures,
+		LastError: b.lastError,
+	}
+
+	if state != BreakerClosed {
+		openedAt := b.openedAt
+		retryAt := openedAt.Add(b.config.OpenTimeout)
+		snapshot.OpenedAt = &openedAt
+		snapshot.RetryAt = &retryAt
+	}
+
+	return snapshot
+}
+
+// BreakerTransport пропускает запросы через предохранитель. Ошибками считаются
+// сетевые ошибки и ответы 5xx; отмена запроса клиентом не учитывается.
+type BreakerTransport struct {
+	Base    http.RoundTripper
+	Breaker *CircuitBreaker
+}
+
+func NewBreakerTransport(base http.RoundTripper, breaker *CircuitBreaker) *BreakerTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &BreakerTransport{
+		Base:    base,
+		Breaker: breaker,
+	}
+}
+
+func (t *BreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.Breaker.Allow() {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		t.Breaker.Release()
+	case err != nil:
+		t.Breaker.Failure(err.Error())
+	case resp.StatusCode >= http.StatusInternalServerError:
+		t.Breaker.Failure(resp.Status)
+	default:
+		t.Breaker.Success()
+	}
+
+	return resp, err
+}
+
+// NewUpstreamClient создает клиент внешнего API с предохранителем, повторными запросами
+// и передачей X-Request-ID
+func NewUpstreamClient(transport http.RoundTripper, retry RetryConfig, breaker *CircuitBreaker) *http.Client {
+	client := NewHTTPClient(NewRequestIDTransport(transport), retry)
+	client.Transport = NewBreakerTransport(client.Transport, breaker)
+	return client
+}
@@ -1,5 +1,11 @@
 package modules
 
+import (
+	"context"
+	"errors"
+	"net"
+)
+
 type APIError interface {
 	GetCode() string
 	GetMessage() string
@@ -29,3 +35,22 @@ func NewError(code, message string) APIError {
 		Message: message,
 	}
 }
+
+// UpstreamErrorCode возвращает код ошибки для неудачного запроса к внешнему API:
+// upstream_unavailable, deadline_exceeded, request_canceled или network_error
+func UpstreamErrorCode(err error) string {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return "upstream_unavailable"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "deadline_exceeded"
+	case errors.Is(err, context.Canceled):
+		return "request_canceled"
+	default:
+		return "network_error"
+	}
+}
@@ -0,0 +1,87 @@
+package modules
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[REDACTED]"
+
+// minSecretLength - более короткие значения не ищутся в тексте, чтобы не
+// портить логи случайными совпадениями
+const minSecretLength = 8
+
+// RedactFormatter скрывает в логах значения секретных полей и известные
+// секреты, встречающиеся в сообщениях и строковых полях
+type RedactFormatter struct {
+	Formatter logrus.Formatter
+
+	keys    map[string]bool
+	mu      sync.RWMutex
+	secrets []string
+}
+
+func NewRedactFormatter(formatter logrus.Formatter, keys ...string) *RedactFormatter {
+	keySet := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		keySet[strings.ToLower(key)] = true
+	}
+	return &RedactFormatter{
+		Formatter: formatter,
+		keys:      keySet,
+	}
+}
+
+// SetSecrets заменяет список секретов, которые нужно скрывать в тексте
+func (f *RedactFormatter) SetSecrets(secrets []string) {
+	filtered := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			filtered = append(filtered, secret)
+		}
+	}
+
+	f.mu.Lock()
+	f.secrets = filtered
+	f.mu.Unlock()
+}
+
+func (f *RedactFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		switch {
+		case f.keys[strings.ToLower(key)]:
+			data[key] = redacted
+		case key == logrus.ErrorKey:
+			if err, ok := value.(error); ok {
+				data[key] = f.redactText(err.Error())
+			} else {
+				data[key] = value
+			}
+		default:
+			if text, ok := value.(string); ok {
+				data[key] = f.redactText(text)
+			} else {
+				data[key] = value
+			}
+		}
+	}
+
+	clone := *entry
+	clone.Data = data
+	clone.Message = f.redactText(entry.Message)
+
+	return f.Formatter.Format(&clone)
+}
+
+func (f *RedactFormatter) redactText(text string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, secret := range f.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package modules
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader - заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - длиннее входящий идентификатор не принимается
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID сохраняет идентификатор запроса в контексте
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext возвращает идентификатор запроса или пустую строку
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID создает случайный идентификатор запроса
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// ValidRequestID проверяет входящий идентификатор: до 128 символов из
+// латинских букв, цифр и -_.:, чтобы его можно было безопасно писать в логи и заголовки
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDTransport передает идентификатор запроса из контекста во внешние API
+type RequestIDTransport struct {
+	Base http.RoundTripper
+}
+
+func NewRequestIDTransport(base http.RoundTripper) *RequestIDTransport {
+	return &RequestIDTransport{Base: base}
+}
+
+func (t *RequestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := RequestIDFromContext(req.Context())
+	if id == "" || req.Header.Get(RequestIDHeader) != "" {
+		return t.Base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestIDHeader, id)
+	return t.Base.RoundTrip(req)
+}
@@ -0,0 +1,209 @@
+package modules
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig - параметры повторных запросов к внешним API
+type RetryConfig struct {
+	// MaxAttempts - всего попыток, включая первую
+	MaxAttempts int
+	// BaseDelay - пауза перед второй попыткой, дальше удваивается
+	BaseDelay time.Duration
+	// MaxDelay - максимальная пауза между попытками, в том числе из Retry-After
+	MaxDelay time.Duration
+	// Jitter - доля паузы (0..1), на которую она случайно уменьшается
+	Jitter float64
+	// RetryOn - коды ответа, после которых запрос повторяется
+	RetryOn []int
+	// Deadline - общее время на все попытки; должно укладываться в таймаут
+	// внешнего запроса ManyChat (10 секунд)
+	Deadline time.Duration
+	// ClientTimeout - таймаут http.Client поверх всех попыток
+	ClientTimeout time.Duration
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.5,
+		RetryOn: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		Deadline:      9 * time.Second,
+		ClientTimeout: 30 * time.Second,
+	}
+}
+
+// RetryTransport повторяет запросы при сетевых ошибках и кодах из RetryOn
+// с экспоненциальной паузой и случайным разбросом
+type RetryTransport struct {
+	Base   http.RoundTripper
+	Config RetryConfig
+}
+
+func NewRetryTransport(base http.RoundTripper, config RetryConfig) *RetryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RetryTransport{
+		Base:   base,
+		Config: config,
+	}
+}
+
+// NewHTTPClient создает клиент для внешних API с повторными запросами поверх transport
+func NewHTTPClient(transport http.RoundTripper, config RetryConfig) *http.Client {
+	timeout := config.ClientTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewRetryTransport(transport, config),
+	}
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	cancel := context.CancelFunc(func() {})
+	if t.Config.Deadline > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.Config.Deadline)
+	}
+
+	attempts := t.Config.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// Тело нельзя прочитать повторно, поэтому запрос не повторяем
+		attempts = 1
+	}
+
+	// finish возвращает результат последней попытки; контекст освобождается
+	// после закрытия тела ответа
+	finish := func(resp *http.Response, err error) (*http.Response, error) {
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := t.Base.RoundTrip(attemptReq)
+
+		if attempt >= attempts || ctx.Err() != nil || !t.shouldRetry(resp, err) {
+			return finish(resp, err)
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if t.Config.MaxDelay > 0 && retryAfter > t.Config.MaxDelay {
+					// Сервер просит подождать дольше, чем мы готовы
+					return finish(resp, err)
+				}
+				delay = retryAfter
+			}
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			// Следующая попытка не успеет до общего дедлайна
+			return finish(resp, err)
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			cancel()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *RetryTransport) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	for _, code := range t.Config.RetryOn {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := float64(t.Config.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if t.Config.MaxDelay > 0 && delay > float64(t.Config.MaxDelay) {
+		delay = float64(t.Config.MaxDelay)
+	}
+	if t.Config.Jitter > 0 {
+		delay -= delay * t.Config.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		delay := time.Until(at)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// cancelOnClose освобождает контекст попыток после чтения тела ответа
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+
+	"tp-go-service/modules/Profiles"
+)
+
+// loadProfiles собирает профили учетных данных: сначала зашифрованные из базы,
+// затем из переменных TP_PROFILE_* (они имеют приоритет)
+func loadProfiles(defaultProfile string) (*Profiles.Registry, error) {
+	registry := Profiles.New(defaultProfile)
+
+	stored, err := store.LoadCredentialProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stored) > 0 {
+		profileCipher, err := Profiles.NewCipher(profilesSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range stored {
+			token, err := profileCipher.Decrypt(row.TokenEncrypted)
+			if err != nil {
+				logger.WithError(err).WithField("profile", row.Name).Error("Не удалось расшифровать профиль")
+				continue
+			}
+
+			registry.Add(Profiles.Profile{
+				Name:   row.Name,
+				Token:  token,
+				TRS:    row.TRS,
+				Marker: row.Marker,
+			})
+		}
+	}
+
+	for _, profile := range Profiles.FromEnv(os.Environ()) {
+		registry.Add(profile)
+	}
+
+	logger.WithField("profiles", registry.Names()).Info("Профили учетных данных загружены")
+
+	return registry, nil
+}
+
+// redactSecrets передает форматтеру логов все секреты сервиса: токены профилей
+// и административный ключ. Вызывается при каждом изменении профилей.
+func redactSecrets(adminAPIKey string) {
+	logFormatter.SetSecrets(append(profiles.Tokens(), adminAPIKey))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules"
+	"tp-go-service/modules/ManyChat"
+	"tp-go-service/modules/RateLimit"
+)
+
+// maxSubscriberPeek - сколько байт тела читать в поисках subscriber_id
+const maxSubscriberPeek = 64 << 10
+
+// routeLimiters - ограничители одного маршрута: по клиенту (API-ключ,
+// а без ключа - IP) и по подписчику ManyChat
+type routeLimiters struct {
+	client     *RateLimit.Limiter
+	subscriber *RateLimit.Limiter
+}
+
+// rateLimit ограничивает частоту запросов к маршруту endpoint. Ставится после
+// requireScope, чтобы корзина клиента определялась по его API-ключу.
+func rateLimit(endpoint string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiters, ok := current().rateLimiters[endpoint]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		client := "ip:" + c.ClientIP()
+		if key, ok := currentKey(c); ok {
+			client = "key:" + strconv.FormatUint(uint64(key.ID), 10)
+		}
+
+		result := limiters.client.Allow(client)
+		limitedBy := client
+
+		if result.Allowed && !limiters.subscriber.Limit().Unlimited() {
+			if subscriberID := subscriberIDFromRequest(c); subscriberID != "" {
+				subscriberResult := limiters.subscriber.Allow("subscriber:" + subscriberID)
+				if !subscriberResult.Allowed || result.Limit == 0 || subscriberResult.Remaining < result.Remaining {
+					result = subscriberResult
+					limitedBy = "subscriber:" + subscriberID
+				}
+			}
+		}
+
+		if result.Limit > 0 {
+			c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		}
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+
+			requestLog(c).WithFields(logrus.Fields{
+				"path":       c.Request.URL.Path,
+				"limited_by": limitedBy,
+			}).Warn("Превышен лимит запросов")
+
+			mc := ManyChat.New()
+			c.Abort()
+			respond(c, http.StatusTooManyRequests, mc.FromError(modules.NewError("rate_limited", "Слишком много запросов, повторите позже")))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// subscriberIDFromRequest достает необязательный subscriber_id из JSON-тела,
+// не мешая дальнейшей привязке тела обработчиком
+func subscriberIDFromRequest(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSubscriberPeek))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		SubscriberID json.RawMessage `json:"subscriber_id"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.SubscriberID) == 0 {
+		return ""
+	}
+
+	// ManyChat подставляет {{user_id}} и строкой, и числом
+	var subscriberID string
+	if err := json.Unmarshal(payload.SubscriberID, &subscriberID); err == nil {
+		return subscriberID
+	}
+	var number json.Number
+	if err := json.Unmarshal(payload.SubscriberID, &number); err == nil {
+		return number.String()
+	}
+	return ""
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules/Brands"
+	"tp-go-service/modules/Config"
+	"tp-go-service/modules/ManyChat"
+	"tp-go-service/modules/Metrics"
+	"tp-go-service/modules/TravelPayouts"
+	"tp-go-service/modules/WeGoTrip"
+)
+
+// runtimeSettings - настройки, которые применяются без перезапуска. Запрос
+// читает снимок целиком, перезагрузка подменяет его атомарно.
+type runtimeSettings struct {
+	config           Config.Config
+	subIDTemplates   *TravelPayouts.SubIDTemplates
+	rateLimiters     map[string]routeLimiters
+	endpointTimeouts map[string]time.Duration
+}
+
+var active atomic.Pointer[runtimeSettings]
+
+// configFile - файл конфигурации, из которого загружены настройки
+var configFile string
+
+// reloadMu не дает двум перезагрузкам идти одновременно
+var reloadMu sync.Mutex
+
+// current возвращает действующие настройки
+func current() *runtimeSettings {
+	return active.Load()
+}
+
+// buildRuntime собирает снимок настроек; ограничители частоты с прежним
+// лимитом берутся из previous
+func buildRuntime(settings Config.Config, previous *runtimeSettings) (*runtimeSettings, error) {
+	var previousLimiters map[string]routeLimiters
+	if previous != nil {
+		previousLimiters = previous.rateLimiters
+	}
+
+	limiters, err := newRateLimiters(settings.RateLimit, previousLimiters)
+	if err != nil {
+		return nil, fmt.Errorf("ограничение частоты запросов: %w", err)
+	}
+
+	templates, err := TravelPayouts.NewSubIDTemplates(settings.Links.SubIDTemplate, settings.Links.SubIDTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("шаблоны sub_id: %w", err)
+	}
+
+	return &runtimeSettings{
+		config:         settings,
+		subIDTemplates: templates,
+		rateLimiters:   limiters,
+		endpointTimeouts: map[string]time.Duration{
+			"getFromLink":  settings.EndpointTimeout(settings.Timeouts.GetFromLink),
+			"getFromLinks": settings.EndpointTimeout(settings.Timeouts.GetFromLinks),
+			"getFromBrand": settings.EndpointTimeout(settings.Timeouts.GetFromBrand),
+			"getFeed":      settings.EndpointTimeout(settings.Timeouts.GetFeed),
+		},
+	}, nil
+}
+
+// loadData читает таблицы городов и каталог брендов: база данных,
+// поверх нее файл брендов; встроенные города, поверх них файл городов
+func loadData(config Config.DataConfig) (*WeGoTrip.Cities, []Brands.Brand, error) {
+	cities := WeGoTrip.DefaultCities()
+	if config.CitiesFile != "" {
+		loaded, err := WeGoTrip.LoadCities(config.CitiesFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("файл городов: %w", err)
+		}
+		cities = loaded
+	}
+
+	brandList, err := store.LoadBrands()
+	if err != nil {
+		return nil, nil, fmt.Errorf("каталог брендов: %w", err)
+	}
+	if config.BrandsFile != "" {
+		overrides, err := Brands.LoadFile(config.BrandsFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("файл брендов: %w", err)
+		}
+		brandList = Brands.Merge(brandList, overrides)
+	}
+
+	return cities, brandList, nil
+}
+
+// weGoTripOptions переводит настройки upstream и feed в параметры клиента WeGoTrip
+func weGoTripOptions(settings Config.Config) WeGoTrip.Options {
+	return WeGoTrip.Options{
+		COMBaseURL:      settings.Upstream.WeGoTripCOMURL,
+		RUBaseURL:       settings.Upstream.WeGoTripRUURL,
+		COMSiteURL:      settings.Feed.COMSiteURL,
+		RUSiteURL:       settings.Feed.RUSiteURL,
+		PageSize:        settings.Feed.PageSize,
+		MaxPageSize:     settings.Feed.MaxPageSize,
+		MinSimilarity:   settings.Feed.CityMinSimilarity,
+		CitySuggestions: settings.Feed.CitySuggestions,
+		DefaultLang:     settings.Feed.DefaultLang,
+		DefaultCurrency: settings.Feed.DefaultCurrency,
+	}
+}
+
+// applyRuntime делает next действующими настройками и подменяет данные
+func applyRuntime(next *runtimeSettings, cities *WeGoTrip.Cities, brandList []Brands.Brand) {
+	level, _ := logrus.ParseLevel(next.config.Log.Level)
+	logger.SetLevel(level)
+
+	ManyChat.SetDefaults(next.config.ManyChat.Version, next.config.ManyChat.ContentType)
+	weGoTrip.SetOptions(weGoTripOptions(next.config))
+	WeGoTrip.SetCities(cities)
+	brands.Replace(brandList)
+
+	active.Store(next)
+}
+
+// reloadReport - итог успешной перезагрузки
+type reloadReport struct {
+	Changed         []string `json:"changed"`
+	RestartRequired []string `json:"restart_required"`
+	Brands          int      `json:"brands"`
+	CitiesCOM       int      `json:"cities_com"`
+	CitiesRU        int      `json:"cities_ru"`
+}
+
+// reload перечитывает конфигурацию и файлы данных. Если хоть что-то не
+// загрузилось или не прошло проверку, продолжают действовать прежние настройки.
+func reload(source string) (reloadReport, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	log := logger.WithField("source", source)
+	previous := current()
+
+	report, next, cities, brandList, err := prepareReload(previous)
+	if err != nil {
+		Metrics.ConfigReloads.WithLabelValues("error").Inc()
+		log.WithError(err).Error("Ошибка перезагрузки конфигурации, действуют прежние настройки")
+		return reloadReport{}, err
+	}
+
+	applyRuntime(next, cities, brandList)
+
+	Metrics.ConfigReloads.WithLabelValues("success").Inc()
+	Metrics.ConfigReloadTime.SetToCurrentTime()
+
+	entry := log.WithFields(logrus.Fields{
+		"changed":    report.Changed,
+		"brands":     report.Brands,
+		"cities_com": report.CitiesCOM,
+		"cities_ru":  report.CitiesRU,
+	})
+	if len(report.RestartRequired) > 0 {
+		entry.WithField("restart_required", report.RestartRequired).
+			Warn("Конфигурация перезагружена, часть настроек применится после перезапуска")
+	} else {
+		entry.Info("Конфигурация перезагружена")
+	}
+
+	return report, nil
+}
+
+func prepareReload(previous *runtimeSettings) (reloadReport, *runtimeSettings, *WeGoTrip.Cities, []Brands.Brand, error) {
+	settings, err := Config.Load(configFile, os.Environ())
+	if err != nil {
+		return reloadReport{}, nil, nil, nil, err
+	}
+
+	restart := settings.KeepStatic(previous.config)
+
+	next, err := buildRuntime(settings, previous)
+	if err != nil {
+		return reloadReport{}, nil, nil, nil, err
+	}
+
+	cities, brandList, err := loadData(settings.Data)
+	if err != nil {
+		return reloadReport{}, nil, nil, nil, err
+	}
+
+	report := reloadReport{
+		Changed:         settings.Diff(previous.config),
+		RestartRequired: restart,
+		Brands:          len(brandList),
+		CitiesCOM:       len(cities.COM),
+		CitiesRU:        len(cities.RU),
+	}
+
+	return report, next, cities, brandList, nil
+}
+
+// fileStamp - время изменения и размер файла; нулевой, если файла нет
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchedFiles возвращает отметки файла конфигурации и файлов данных
+func watchedFiles() map[string]fileStamp {
+	data := current().config.Data
+
+	stamps := make(map[string]fileStamp, 3)
+	for _, path := range []string{configFile, data.CitiesFile, data.BrandsFile} {
+		if path == "" {
+			continue
+		}
+		stamp := fileStamp{}
+		if info, err := os.Stat(path); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		stamps[path] = stamp
+	}
+	return stamps
+}
+
+// watchReload перезагружает конфигурацию по SIGHUP и при изменении файлов,
+// которые проверяются раз в interval (0 - только по сигналу)
+func watchReload(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	stamps := watchedFiles()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload("sighup")
+		case <-tick:
+			if maps.Equal(stamps, watchedFiles()) {
+				continue
+			}
+			reload("file_change")
+		}
+		// Отметки обновляются и после неудачной перезагрузки, чтобы
+		// не повторять ее, пока файл снова не изменится
+		stamps = watchedFiles()
+	}
+}
+
+// reloadConfig перезагружает конфигурацию по запросу администратора
+func reloadConfig(c *gin.Context) {
+	report, err := reload("api")
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
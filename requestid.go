@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules"
+)
+
+// requestIDContextKey - ключ gin-контекста с идентификатором запроса
+const requestIDContextKey = "request_id"
+
+// requestID принимает X-Request-ID клиента или создает новый, сохраняет его
+// в контексте запроса и возвращает в заголовке ответа
+func requestID(c *gin.Context) {
+	id := c.GetHeader(modules.RequestIDHeader)
+	if !modules.ValidRequestID(id) {
+		id = modules.NewRequestID()
+	}
+
+	c.Set(requestIDContextKey, id)
+	c.Request = c.Request.WithContext(modules.WithRequestID(c.Request.Context(), id))
+	c.Header(modules.RequestIDHeader, id)
+
+	c.Next()
+}
+
+// requestLog возвращает запись лога с идентификатором запроса
+func requestLog(c *gin.Context) *logrus.Entry {
+	return contextLog(c.Request.Context())
+}
+
+// contextLog возвращает запись лога с идентификатором запроса из контекста
+func contextLog(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(logger)
+	if id := modules.RequestIDFromContext(ctx); id != "" {
+		entry = entry.WithField("request_id", id)
+	}
+	return entry
+}
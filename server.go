@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"tp-go-service/modules/Cache"
+	"tp-go-service/modules/Config"
+)
+
+// draining выставляется при получении сигнала остановки
+var draining atomic.Bool
+
+// serve запускает HTTP-сервер и возвращается после корректной остановки по SIGTERM/SIGINT
+func serve(handler http.Handler, addr string, config Config.ServerConfig) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		ErrorLog:          log.New(logger.WriterLevel(logrus.WarnLevel), "", 0),
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	logger.Info("Сервер запущен на ", addr)
+
+	var sig os.Signal
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("Ошибка запуска сервера: ", err)
+		}
+		return
+	case sig = <-signals:
+	}
+
+	draining.Store(true)
+	srv.SetKeepAlivesEnabled(false)
+
+	logger.WithFields(logrus.Fields{
+		"signal":           sig.String(),
+		"drain_delay":      config.DrainDelay.String(),
+		"shutdown_timeout": config.ShutdownTimeout.String(),
+	}).Info("Получен сигнал остановки, сервис выводится из балансировки: запросы еще обслуживаются, остановка через drain_delay")
+
+	// повторный сигнал пропускает ожидание балансировщика
+	select {
+	case <-time.After(config.DrainDelay):
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.WithError(err).Warn("Не все запросы завершились до истечения SHUTDOWN_TIMEOUT")
+		srv.Close()
+	}
+
+	logger.Info("Сервер остановлен")
+}
+
+// flushLinkCache сохраняет состояние кэша ссылок перед остановкой
+func flushLinkCache() {
+	flusher, ok := linkCache.(Cache.Flusher)
+	if !ok {
+		return
+	}
+
+	if err := flusher.Flush(); err != nil {
+		logger.WithError(err).Warn("Ошибка сохранения кэша ссылок")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"tp-go-service/modules/Tracing"
+)
+
+// traceRequests открывает серверный спан на каждый запрос, продолжая
+// трассировку из заголовка traceparent клиента
+func traceRequests(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := Tracing.Tracer().Start(ctx, c.Request.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.HTTPRoute(route),
+			attribute.String("tp.request_id", c.GetString(requestIDContextKey)),
+		),
+	)
+	defer span.End()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// traceErrorCodes отмечает в спане запроса коды ошибок из ответа
+func traceErrorCodes(c *gin.Context, errorCodes []string) {
+	if len(errorCodes) == 0 {
+		return
+	}
+
+	span := trace.SpanFromContext(c.Request.Context())
+	span.SetAttributes(attribute.StringSlice("tp.error_codes", errorCodes))
+	Tracing.SetError(span, errorCodes[0], "ответ с ошибкой "+errorCodes[0])
+}